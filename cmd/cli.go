@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Client subcommands. They talk to a running server over its HTTP API, since
+// indexes only live in the server's memory.
+
+// Register the flags shared by every client subcommand
+func clientFlags(fs *flag.FlagSet) (server, index *string) {
+	server = fs.String("server", "http://localhost:8080", "Victor server URL")
+	index = fs.String("index", defaultIndexName, "Index name")
+	return server, index
+}
+
+// Build the URL of a named index route
+func indexURL(server, index string, action ...string) string {
+	parts := append([]string{strings.TrimRight(server, "/"), "index", url.PathEscape(index)}, action...)
+	return strings.Join(parts, "/")
+}
+
+// Parse a comma separated list of floats
+func parseVector(s string) ([]float32, error) {
+	fields := strings.Split(s, ",")
+	vector := make([]float32, len(fields))
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(f), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector component %q: %v", f, err)
+		}
+		vector[i] = float32(v)
+	}
+	return vector, nil
+}
+
+// Send a JSON request and copy the response body to stdout
+func doRequest(method, target string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return send(method, target, "application/json", bytes.NewReader(payload))
+}
+
+// Send a raw request body and copy the response body to stdout
+func send(method, target, contentType string, body io.Reader) error {
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if token := os.Getenv("VICTOR_AUTH_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(os.Stdout, resp.Body); err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Print an error and exit
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	os.Exit(1)
+}
+
+// victor create: create (or replace) an index
+func createCommand(args []string) {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	server, index := clientFlags(fs)
+	indexType := fs.Int("type", 0, "Index type")
+	method := fs.Int("method", 0, "Distance method")
+	dims := fs.Uint("dims", 0, "Vector dimensions")
+	fs.Parse(args)
+
+	req := CreateIndexRequest{IndexType: *indexType, Method: *method, Dims: *dims}
+	if err := doRequest("POST", indexURL(*server, *index), req); err != nil {
+		fail(err)
+	}
+}
+
+// victor insert: insert one vector given by flags, or one InsertRequest per
+// line of JSON read from stdin when -vector is not set
+func insertCommand(args []string) {
+	fs := flag.NewFlagSet("insert", flag.ExitOnError)
+	server, index := clientFlags(fs)
+	id := fs.Uint64("id", 0, "Vector ID")
+	vectorStr := fs.String("vector", "", "Comma separated vector; read JSON lines from stdin if empty")
+	upsert := fs.Bool("upsert", false, "Replace vectors whose ID already exists")
+	fs.Parse(args)
+
+	query := ""
+	if *upsert {
+		query = "?upsert=true"
+	}
+	if *vectorStr != "" {
+		vector, err := parseVector(*vectorStr)
+		if err != nil {
+			fail(err)
+		}
+		if err := doRequest("POST", indexURL(*server, *index, "vector")+query, InsertRequest{ID: *id, Vector: vector}); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	// Stream stdin to the bulk endpoint, which reports failed records
+	// without stopping
+	if err := send("POST", indexURL(*server, *index, "bulk")+query, "application/x-ndjson", os.Stdin); err != nil {
+		fail(err)
+	}
+}
+
+// victor search: search the closest match, or the top N with -n
+func searchCommand(args []string) {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	server, index := clientFlags(fs)
+	vectorStr := fs.String("vector", "", "Comma separated query vector")
+	n := fs.Int("n", 1, "Number of results")
+	fs.Parse(args)
+
+	if *vectorStr == "" {
+		fail(fmt.Errorf("-vector is required"))
+	}
+	vector, err := parseVector(*vectorStr)
+	if err != nil {
+		fail(err)
+	}
+
+	req := SearchRequest{Vector: vector, Dims: len(vector), TopN: *n}
+	action := "search"
+	if *n > 1 {
+		action = "search_n"
+	}
+	if err := doRequest("POST", indexURL(*server, *index, action), req); err != nil {
+		fail(err)
+	}
+}
@@ -0,0 +1,238 @@
+//go:build grpc
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"victor"
+	"victor/proto/victorpb"
+)
+
+func init() {
+	serveGRPC = startGRPC
+}
+
+// gRPC front end. It works on the same index registry as the HTTP handlers.
+type grpcServer struct {
+	victorpb.UnimplementedVictorServer
+}
+
+// Start the gRPC server in the background and return a function that stops
+// it gracefully
+func startGRPC(addr string, tokens []string) (func(), error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []grpc.ServerOption
+	if len(tokens) > 0 {
+		opts = append(opts,
+			grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				if err := grpcAuth(ctx, tokens); err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}),
+			grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				if err := grpcAuth(ss.Context(), tokens); err != nil {
+					return err
+				}
+				return handler(srv, ss)
+			}),
+		)
+	}
+
+	s := grpc.NewServer(opts...)
+	victorpb.RegisterVictorServer(s, &grpcServer{})
+	go func() {
+		if err := s.Serve(lis); err != nil {
+			fatal("gRPC server error", "error", err)
+		}
+	}()
+	slog.Info("Starting Victor gRPC server", "addr", addr)
+	return s.GracefulStop, nil
+}
+
+// Check the bearer token sent in the "authorization" metadata
+func grpcAuth(ctx context.Context, tokens []string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, h := range md.Get("authorization") {
+		if validToken(h, tokens) {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "Unauthorized")
+}
+
+// Map index errors to gRPC codes, following the HTTP status mapping
+func grpcError(err error) error {
+	code := codes.Internal
+	switch errorStatus(err) {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusNotImplemented:
+		code = codes.Unimplemented
+	case http.StatusGatewayTimeout:
+		code = codes.DeadlineExceeded
+	case statusClientClosedRequest:
+		code = codes.Canceled
+	}
+	return status.Error(code, err.Error())
+}
+
+// An empty index name selects the default index
+func grpcIndexName(name string) string {
+	if name == "" {
+		return defaultIndexName
+	}
+	return name
+}
+
+func (s *grpcServer) Insert(ctx context.Context, req *victorpb.InsertRequest) (*victorpb.InsertResponse, error) {
+	name := grpcIndexName(req.GetIndex())
+	ni, err := acquireIndex(ctx, name)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	defer ni.unlock()
+
+	insert := ni.insert
+	if req.GetUpsert() {
+		insert = ni.upsert
+	}
+	if err := insert(ctx, req.GetId(), req.GetVector()); err != nil {
+		slog.Warn("Insert failed", "index", name, "error", err)
+		return nil, grpcError(err)
+	}
+	insertsTotal.WithLabelValues(name).Inc()
+	return &victorpb.InsertResponse{}, nil
+}
+
+func (s *grpcServer) Delete(ctx context.Context, req *victorpb.DeleteRequest) (*victorpb.DeleteResponse, error) {
+	name := grpcIndexName(req.GetIndex())
+	ni, err := acquireIndex(ctx, name)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	defer ni.unlock()
+
+	if err := ni.delete(req.GetId()); err != nil {
+		slog.Warn("Delete failed", "index", name, "error", err)
+		return nil, grpcError(err)
+	}
+	deletesTotal.WithLabelValues(name).Inc()
+	return &victorpb.DeleteResponse{}, nil
+}
+
+// Run a search of n results against the named index, with the same metrics
+// and empty-index handling as the HTTP handlers
+func (s *grpcServer) search(ctx context.Context, req *victorpb.SearchRequest, endpoint string, n int) ([]SearchResult, error) {
+	name := grpcIndexName(req.GetIndex())
+	label := indexLabel(name)
+	searchesTotal.WithLabelValues(label, endpoint).Inc()
+
+	ctx, cancel := searchContext(ctx)
+	defer cancel()
+	ni, err := acquireIndex(ctx, name)
+	if err != nil {
+		searchFailuresTotal.WithLabelValues(label, endpoint).Inc()
+		return nil, grpcError(err)
+	}
+
+	var results []SearchResult
+	err = runSearch(ctx, ni, func(ctx context.Context) error {
+		start := time.Now()
+		matches, err := ni.index.SearchNContext(ctx, req.GetVector(), len(req.GetVector()), n)
+		searchDuration.WithLabelValues(name, endpoint).Observe(time.Since(start).Seconds())
+		if errors.Is(err, victor.ErrIndexEmpty) {
+			matches, err = nil, nil
+		}
+		if err != nil {
+			return err
+		}
+		results = ni.translate(matches)
+		return nil
+	})
+	if err != nil {
+		slog.Warn("Search failed", "index", name, "endpoint", endpoint, "error", err)
+		searchFailuresTotal.WithLabelValues(label, endpoint).Inc()
+		return nil, grpcError(err)
+	}
+
+	searchResults.WithLabelValues(name, endpoint).Observe(float64(len(results)))
+	return results, nil
+}
+
+// Apply the -default-top-n and -max-top-n limits
+func grpcTopN(n int32) (int, error) {
+	if n == 0 {
+		return defaultTopN, nil
+	}
+	if n < 0 || int(n) > maxTopN {
+		return 0, status.Errorf(codes.InvalidArgument, "top_n must be between 1 and %d", maxTopN)
+	}
+	return int(n), nil
+}
+
+func toMatches(results []SearchResult) []*victorpb.Match {
+	matches := make([]*victorpb.Match, len(results))
+	for i, r := range results {
+		matches[i] = &victorpb.Match{Id: r.ID, Distance: r.Distance, Similarity: r.Similarity}
+	}
+	return matches
+}
+
+func (s *grpcServer) Search(ctx context.Context, req *victorpb.SearchRequest) (*victorpb.SearchResponse, error) {
+	results, err := s.search(ctx, req, "grpc_search", 1)
+	if err != nil {
+		return nil, err
+	}
+	return &victorpb.SearchResponse{Matches: toMatches(results)}, nil
+}
+
+func (s *grpcServer) SearchN(ctx context.Context, req *victorpb.SearchRequest) (*victorpb.SearchResponse, error) {
+	n, err := grpcTopN(req.GetTopN())
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.search(ctx, req, "grpc_search_n", n)
+	if err != nil {
+		return nil, err
+	}
+	return &victorpb.SearchResponse{Matches: toMatches(results)}, nil
+}
+
+func (s *grpcServer) SearchNStream(req *victorpb.SearchRequest, stream victorpb.Victor_SearchNStreamServer) error {
+	n, err := grpcTopN(req.GetTopN())
+	if err != nil {
+		return err
+	}
+	// The index lock is released before streaming, so a slow client does
+	// not block writers
+	results, err := s.search(stream.Context(), req, "grpc_search_n_stream", n)
+	if err != nil {
+		return err
+	}
+	for _, m := range toMatches(results) {
+		if err := stream.Send(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
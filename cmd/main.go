@@ -1,25 +1,201 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"math"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"victor"
 )
 
-// Global index instance and mutex for thread safety
+// No-match modes for the search endpoints
+const (
+	noMatchEmpty = "empty" // 200 with an empty result
+	noMatch404   = "404"   // 404 Not Found
+)
+
+// Non-standard status for requests the client abandoned, as used by nginx.
+// The client never sees it, but it shows up in logs and metrics.
+const statusClientClosedRequest = 499
+
+// Name of the index served by the legacy (unnamed) routes
+const defaultIndexName = "default"
+
+// Index names that /index/{name} can't serve because a legacy route with the
+// same path shadows them
+var reservedIndexNames = map[string]bool{
+	"vector": true, // /index/vector
+}
+
+// namedIndex is an index served under /index/{name}, with its own lock. The
+// lock is a one-slot channel rather than a mutex so that waiting for it can
+// be abandoned when the request context is done.
+//
+// The C index reports match IDs as a C int, so vectors are stored under
+// small internal IDs and mapped back to the caller's uint64 IDs.
+type namedIndex struct {
+	sem        chan struct{}
+	index      *victor.Index
+	nextID     uint64
+	toInternal map[uint64]uint64
+	toUser     map[int]uint64
+}
+
+// Wrap a freshly allocated index
+func newNamedIndex(idx *victor.Index) *namedIndex {
+	return &namedIndex{
+		sem:        make(chan struct{}, 1),
+		index:      idx,
+		nextID:     1,
+		toInternal: make(map[uint64]uint64),
+		toUser:     make(map[int]uint64),
+	}
+}
+
+// Take the index lock, giving up when ctx is done
+func (ni *namedIndex) lock(ctx context.Context) error {
+	select {
+	case ni.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release the index lock
+func (ni *namedIndex) unlock() {
+	<-ni.sem
+}
+
+// Free the index once in-flight requests on it are done. Later requests
+// waiting for the lock see a nil index and report it as not found.
+func (ni *namedIndex) destroy() {
+	ni.lock(context.Background())
+	ni.index.DestroyIndex()
+	ni.index = nil
+	ni.unlock()
+}
+
+// Insert a vector under the next internal ID and return that ID
+func (ni *namedIndex) add(ctx context.Context, vector []float32) (uint64, error) {
+	if ni.nextID > victor.MaxID {
+		return 0, fmt.Errorf("index ID space exhausted")
+	}
+
+	internal := ni.nextID
+	if err := ni.index.InsertContext(ctx, internal, vector); err != nil {
+		return 0, err
+	}
+	ni.nextID++
+	return internal, nil
+}
+
+// Insert a vector under the caller's ID
+func (ni *namedIndex) insert(ctx context.Context, id uint64, vector []float32) error {
+	if _, exists := ni.toInternal[id]; exists {
+		return victor.ErrDuplicate
+	}
+
+	internal, err := ni.add(ctx, vector)
+	if err != nil {
+		return err
+	}
+	ni.toInternal[id] = internal
+	ni.toUser[int(internal)] = id
+	return nil
+}
+
+// Insert a vector under the caller's ID, replacing any existing one. The new
+// vector goes in before the old one is removed, so a failed upsert leaves
+// the index unchanged.
+func (ni *namedIndex) upsert(ctx context.Context, id uint64, vector []float32) error {
+	old, exists := ni.toInternal[id]
+	if !exists {
+		return ni.insert(ctx, id, vector)
+	}
+
+	internal, err := ni.add(ctx, vector)
+	if err != nil {
+		return err
+	}
+	if err := ni.index.Delete(old); err != nil {
+		ni.index.Delete(internal)
+		return err
+	}
+	delete(ni.toUser, int(old))
+	ni.toInternal[id] = internal
+	ni.toUser[int(internal)] = id
+	return nil
+}
+
+// Delete a vector by the caller's ID
+func (ni *namedIndex) delete(id uint64) error {
+	internal, exists := ni.toInternal[id]
+	if !exists {
+		return victor.ErrInvalidID
+	}
+	if err := ni.index.Delete(internal); err != nil {
+		return err
+	}
+	delete(ni.toInternal, id)
+	delete(ni.toUser, int(internal))
+	return nil
+}
+
+// Translate index matches to the caller's IDs. SearchN already leaves out
+// empty slots, so only IDs deleted meanwhile are skipped.
+func (ni *namedIndex) translate(results []victor.MatchResult) []SearchResult {
+	out := make([]SearchResult, 0, len(results))
+	for _, r := range results {
+		id, exists := ni.toUser[r.ID]
+		if !exists {
+			continue
+		}
+		out = append(out, SearchResult{ID: id, Distance: r.Distance, Similarity: r.Similarity})
+	}
+	return out
+}
+
+// Global index registry and mutex for thread safety
 var (
-	indexInstance *victor.Index
-	mutex         sync.Mutex
+	indexes      = make(map[string]*namedIndex)
+	indexesMutex sync.RWMutex
+	noMatchMode  = noMatchEmpty
+	maxBodyBytes = int64(10 << 20)
+	defaultTopN  = 10
+	maxTopN      = 1000
+	maxBatch     = 100
+
+	// Longest a handler waits for a search before answering 504, 0 for no limit
+	searchTimeout time.Duration
+
+	// Norm check applied to new COSINE indexes, set by -norm-check
+	normCheckMode = victor.NormCheckOff
+	normChecks    = map[string]int{"off": victor.NormCheckOff, "warn": victor.NormCheckWarn, "strict": victor.NormCheckStrict}
+
+	// Returned by acquireIndex for a missing or destroyed index
+	errIndexNotFound = errors.New("Index not initialized")
+
+	// Set by grpc.go when built with -tags grpc
+	serveGRPC func(addr string, tokens []string) (stop func(), err error)
 )
 
 // Response structure
@@ -49,235 +225,910 @@ type SearchRequest struct {
 	TopN   int       `json:"top_n,omitempty"`
 }
 
+// Version response structure
+type VersionResponse struct {
+	Library  string `json:"library"`
+	Bindings string `json:"bindings"`
+}
+
+// Search result structure, with the caller's vector ID
+type SearchResult struct {
+	ID         uint64  `json:"id"`
+	Distance   float32 `json:"distance"`
+	Similarity float32 `json:"similarity"`
+}
+
+// Batch search request structure
+type SearchBatchRequest struct {
+	Vectors [][]float32 `json:"vectors"`
+	Dims    int         `json:"dims"`
+	TopN    int         `json:"top_n,omitempty"`
+}
+
+// Health/readiness response structure
+type StatusResponse struct {
+	Status string `json:"status"`
+	Index  string `json:"index,omitempty"`
+}
+
+// Failed record in a bulk insert, numbered by its line in the stream from 1
+type BulkError struct {
+	Record int    `json:"record"`
+	ID     uint64 `json:"id"`
+	Error  string `json:"error"`
+}
+
+// Bulk insert summary
+type BulkResponse struct {
+	Inserted int         `json:"inserted"`
+	Failed   int         `json:"failed"`
+	Errors   []BulkError `json:"errors,omitempty"`
+}
+
+// Return the value of an environment variable, or def if it is unset or empty
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// Report whether a listening address binds every interface
+func isWildcard(addr string) bool {
+	return addr == "" || addr == "0.0.0.0" || addr == "::"
+}
+
+// Log a fatal error and exit
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 // Logger middleware
 func logRequest(r *http.Request) {
-	log.Printf("%s %s", r.Method, r.URL.Path)
+	slog.Info("Request", "method", r.Method, "path", r.URL.Path)
 }
 
-// Create an index (destroy existing one if necessary)
-func createIndexHandler(w http.ResponseWriter, r *http.Request) {
+// Write a JSON reply with the given status, indented if the request asks
+// for ?pretty=true
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	if r.URL.Query().Get("pretty") == "true" {
+		enc.SetIndent("", "  ")
+	}
+	enc.Encode(v)
+}
+
+// Reply to a search that found no matches according to noMatchMode
+func writeNoMatch(w http.ResponseWriter, r *http.Request, result interface{}) {
+	if noMatchMode == noMatch404 {
+		http.Error(w, "No matches found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, Response{Message: "No matches found", Result: result})
+}
+
+// Describe the allowed values of an enum, e.g. "0 (L2NORM), 1 (COSINE)"
+func describeEnum(values map[int]string) string {
+	keys := make([]int, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	options := make([]string, len(keys))
+	for i, k := range keys {
+		options[i] = fmt.Sprintf("%d (%s)", k, values[k])
+	}
+	return strings.Join(options, ", ")
+}
+
+// Check a create request against the supported index types, methods and dims
+func validateCreateRequest(req *CreateIndexRequest) error {
+	if _, ok := victor.IndexTypes[req.IndexType]; !ok {
+		return fmt.Errorf("invalid index_type %d, valid options: %s", req.IndexType, describeEnum(victor.IndexTypes))
+	}
+	if _, ok := victor.Methods[req.Method]; !ok {
+		return fmt.Errorf("invalid method %d, valid options: %s", req.Method, describeEnum(victor.Methods))
+	}
+	if req.Dims == 0 || req.Dims > math.MaxUint16 {
+		return fmt.Errorf("invalid dims %d, must be between 1 and %d", req.Dims, math.MaxUint16)
+	}
+	return nil
+}
+
+// Decode a JSON request body of at most maxBodyBytes. On failure it replies
+// 413 (body too large) or 400 and returns false.
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}, op string) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("Request body exceeds %d bytes", maxBodyBytes), http.StatusRequestEntityTooLarge)
+		slog.Warn(op + " failed: Request body too large")
+		return false
+	}
+	http.Error(w, "Invalid JSON input", http.StatusBadRequest)
+	slog.Warn(op + " failed: Invalid JSON input")
+	return false
+}
+
+// Map an index error to an HTTP status: client mistakes are 4xx, an
+// operation the index type lacks is 501, a request that ran out of time is
+// 504 and anything else is a server failure. An empty index is not an error;
+// the search handlers reply with no matches instead.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, victor.ErrInvalidDimensions), errors.Is(err, victor.ErrInvalidVector),
+		errors.Is(err, victor.ErrNotNormalized):
+		return http.StatusBadRequest
+	case errors.Is(err, victor.ErrDuplicate):
+		return http.StatusConflict
+	case errors.Is(err, victor.ErrInvalidID), errors.Is(err, errIndexNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, victor.ErrNotSupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// Apply the default top N when omitted and reject values over the cap, so a
+// request cannot make SearchN allocate an arbitrary number of results
+func resolveTopN(w http.ResponseWriter, n *int, op string) bool {
+	if *n == 0 {
+		*n = defaultTopN
+	}
+	if *n < 0 || *n > maxTopN {
+		http.Error(w, fmt.Sprintf("top_n must be between 1 and %d", maxTopN), http.StatusBadRequest)
+		slog.Warn(op+" failed: Invalid top_n", "top_n", *n, "max", maxTopN)
+		return false
+	}
+	return true
+}
+
+// Reject batches larger than -max-batch, so one request cannot queue an
+// arbitrary number of searches
+func checkBatchSize(w http.ResponseWriter, n int, op string) bool {
+	if n > maxBatch {
+		http.Error(w, fmt.Sprintf("batch must hold at most %d vectors", maxBatch), http.StatusBadRequest)
+		slog.Warn(op+" failed: Batch too large", "vectors", n, "max", maxBatch)
+		return false
+	}
+	return true
+}
+
+// Look up an index by name and lock it, waiting no longer than ctx allows.
+// Returns errIndexNotFound if it does not exist, or the context error if the
+// lock could not be taken in time; otherwise the caller must unlock it when
+// done.
+func acquireIndex(ctx context.Context, name string) (*namedIndex, error) {
+	indexesMutex.RLock()
+	ni := indexes[name]
+	indexesMutex.RUnlock()
+	if ni == nil {
+		return nil, errIndexNotFound
+	}
+
+	if err := ni.lock(ctx); err != nil {
+		return nil, err
+	}
+	if ni.index == nil {
+		// Destroyed while we were waiting for the lock
+		ni.unlock()
+		return nil, errIndexNotFound
+	}
+	return ni, nil
+}
+
+// Bound a search by -search-timeout. The deadline covers the wait for the
+// index lock as well as the search itself, so it must be set up before
+// acquireIndex.
+func searchContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if searchTimeout > 0 {
+		return context.WithTimeout(ctx, searchTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Run a search on an index locked by acquireIndex, giving up when ctx (see
+// searchContext) is done. A search that is given up on keeps running in its
+// goroutine, since the C call can't be interrupted, and only then releases
+// the index lock, so the index is never destroyed under it. The handler is
+// freed either way.
+func runSearch(ctx context.Context, ni *namedIndex, search func(context.Context) error) error {
+	done := make(chan error, 1)
+	go func() {
+		defer ni.unlock()
+		done <- search(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Bind a handler to a fixed index name (used by the legacy routes)
+func withIndex(name string, handler func(http.ResponseWriter, *http.Request, string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, name)
+	}
+}
+
+// Route /index/{name} and /index/{name}/{action} to the handlers
+func namedIndexHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/index/"), "/"), "/")
+	name := parts[0]
+	if name == "" || len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+	if reservedIndexNames[name] {
+		http.Error(w, fmt.Sprintf("Index name %q is reserved", name), http.StatusBadRequest)
+		slog.Warn("Invalid index name", "index", name)
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case "POST":
+			createIndexHandler(w, r, name)
+		case "DELETE":
+			destroyIndexHandler(w, r, name)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			slog.Warn("Invalid HTTP method", "method", r.Method)
+		}
+		return
+	}
+
+	switch parts[1] {
+	case "vector":
+		vectorHandler(w, r, name)
+	case "search":
+		searchVectorHandler(w, r, name)
+	case "search_n":
+		searchNVectorHandler(w, r, name)
+	case "search_batch":
+		searchBatchHandler(w, r, name)
+	case "stats":
+		statsHandler(w, r, name)
+	case "bulk":
+		bulkHandler(w, r, name)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Create an index (destroy existing one with the same name if necessary)
+func createIndexHandler(w http.ResponseWriter, r *http.Request, name string) {
 	logRequest(r)
-	mutex.Lock()
-	defer mutex.Unlock()
 
 	var req CreateIndexRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON input", http.StatusBadRequest)
-		log.Println("Index creation failed: Invalid JSON input")
+	if !decodeRequest(w, r, &req, "Index creation") {
 		return
 	}
 
-	// If an index already exists, destroy it before creating a new one
-	if indexInstance != nil {
-		indexInstance.DestroyIndex()
-		indexInstance = nil
-		log.Println("Previous index destroyed")
+	if err := validateCreateRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Warn("Index creation failed", "error", err)
+		return
 	}
 
 	idx, err := victor.AllocIndex(req.IndexType, req.Method, uint16(req.Dims))
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to create index: %v", err), http.StatusInternalServerError)
-		log.Println("Error creating index:", err)
+		slog.Error("Error creating index", "index", name, "error", err)
 		return
 	}
+	idx.SetNormCheck(normCheckMode, 0)
 
-	indexInstance = idx
-	log.Printf("Index created: Type=%d, Method=%d, Dims=%d\n", req.IndexType, req.Method, req.Dims)
-	json.NewEncoder(w).Encode(Response{Message: "Index created successfully"})
+	indexesMutex.Lock()
+	old := indexes[name]
+	indexes[name] = newNamedIndex(idx)
+	indexesMutex.Unlock()
+
+	// If the index already existed, destroy it once in-flight requests on it
+	// are done. The registry lock is not held here so probes never wait on it.
+	if old != nil {
+		old.destroy()
+		slog.Info("Previous index destroyed", "index", name)
+	}
+
+	slog.Info("Index created", "index", name, "type", req.IndexType, "method", req.Method, "dims", req.Dims)
+	writeJSON(w, r, http.StatusOK, Response{Message: "Index created successfully"})
 }
 
 // Search for the closest match
-func searchVectorHandler(w http.ResponseWriter, r *http.Request) {
+func searchVectorHandler(w http.ResponseWriter, r *http.Request, name string) {
 	logRequest(r)
-	mutex.Lock()
-	defer mutex.Unlock()
+	label := indexLabel(name)
+	searchesTotal.WithLabelValues(label, "search").Inc()
 
-	if indexInstance == nil {
-		http.Error(w, "Index not initialized", http.StatusNotFound)
-		log.Println("Search failed: Index not initialized")
+	var req SearchRequest
+	if !decodeRequest(w, r, &req, "Search") {
+		searchFailuresTotal.WithLabelValues(label, "search").Inc()
 		return
 	}
 
-	var req SearchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON input", http.StatusBadRequest)
-		log.Println("Search failed: Invalid JSON input")
+	ctx, cancel := searchContext(r.Context())
+	defer cancel()
+	ni, err := acquireIndex(ctx, name)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		slog.Warn("Search failed", "index", name, "error", err)
+		searchFailuresTotal.WithLabelValues(label, "search").Inc()
 		return
 	}
 
-	result, err := indexInstance.Search(req.Vector, req.Dims)
+	var results []SearchResult
+	err = runSearch(ctx, ni, func(ctx context.Context) error {
+		start := time.Now()
+		match, err := ni.index.SearchContext(ctx, req.Vector, req.Dims)
+		searchDuration.WithLabelValues(name, "search").Observe(time.Since(start).Seconds())
+		if errors.Is(err, victor.ErrIndexEmpty) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		results = ni.translate([]victor.MatchResult{*match})
+		return nil
+	})
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Search failed: %v", err), http.StatusInternalServerError)
-		log.Println("Search failed:", err)
+		http.Error(w, fmt.Sprintf("Search failed: %v", err), errorStatus(err))
+		slog.Warn("Search failed", "index", name, "error", err)
+		searchFailuresTotal.WithLabelValues(label, "search").Inc()
+		return
+	}
+
+	searchResults.WithLabelValues(name, "search").Observe(float64(len(results)))
+	if len(results) == 0 {
+		slog.Debug("Search successful: No matches found", "index", name)
+		writeNoMatch(w, r, nil)
 		return
 	}
 
-	log.Printf("Search successful: ID=%d, Distance=%.4f\n", result.ID, result.Distance)
-	json.NewEncoder(w).Encode(Response{Message: "Search successful", Result: result})
+	result := results[0]
+	slog.Debug("Search successful", "index", name, "id", result.ID, "distance", result.Distance)
+	writeJSON(w, r, http.StatusOK, Response{Message: "Search successful", Result: result})
 }
 
 // Search for the top N closest matches
-func searchNVectorHandler(w http.ResponseWriter, r *http.Request) {
+func searchNVectorHandler(w http.ResponseWriter, r *http.Request, name string) {
 	logRequest(r)
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	if indexInstance == nil {
-		http.Error(w, "Index not initialized", http.StatusNotFound)
-		log.Println("SearchN failed: Index not initialized")
-		return
-	}
+	label := indexLabel(name)
+	searchesTotal.WithLabelValues(label, "search_n").Inc()
 
 	var req SearchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON input", http.StatusBadRequest)
-		log.Println("SearchN failed: Invalid JSON input")
+	if !decodeRequest(w, r, &req, "SearchN") || !resolveTopN(w, &req.TopN, "SearchN") {
+		searchFailuresTotal.WithLabelValues(label, "search_n").Inc()
 		return
 	}
 
-	results, err := indexInstance.SearchN(req.Vector, req.Dims, req.TopN)
+	ctx, cancel := searchContext(r.Context())
+	defer cancel()
+	ni, err := acquireIndex(ctx, name)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Search failed: %v", err), http.StatusInternalServerError)
-		log.Println("SearchN failed:", err)
+		http.Error(w, err.Error(), errorStatus(err))
+		slog.Warn("SearchN failed", "index", name, "error", err)
+		searchFailuresTotal.WithLabelValues(label, "search_n").Inc()
 		return
 	}
 
-	// Convert +Inf distances to the max finite float
-	for i := range results {
-		if math.IsInf(float64(results[i].Distance), 1) {
-			results = results[0:i]
-			break
+	var results []SearchResult
+	err = runSearch(ctx, ni, func(ctx context.Context) error {
+		start := time.Now()
+		matches, err := ni.index.SearchNContext(ctx, req.Vector, req.Dims, req.TopN)
+		searchDuration.WithLabelValues(name, "search_n").Observe(time.Since(start).Seconds())
+		if errors.Is(err, victor.ErrIndexEmpty) {
+			matches, err = nil, nil
+		}
+		if err != nil {
+			return err
 		}
+		results = ni.translate(matches)
+		return nil
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Search failed: %v", err), errorStatus(err))
+		slog.Warn("SearchN failed", "index", name, "error", err)
+		searchFailuresTotal.WithLabelValues(label, "search_n").Inc()
+		return
 	}
 
+	searchResults.WithLabelValues(name, "search_n").Observe(float64(len(results)))
+
 	if len(results) == 0 {
-		log.Println("SearchN successful: No matches found")
-		json.NewEncoder(w).Encode(Response{Message: "Search successful", Result: []victor.MatchResult{}})
+		slog.Debug("SearchN successful: No matches found", "index", name)
+		writeNoMatch(w, r, []SearchResult{})
 		return
 	}
 
-	log.Printf("SearchN successful: Found %d results\n", len(results))
-	json.NewEncoder(w).Encode(Response{Message: "Search successful", Result: results})
+	slog.Debug("SearchN successful", "index", name, "results", len(results))
+	writeJSON(w, r, http.StatusOK, Response{Message: "Search successful", Result: results})
 }
 
-// Handles vector insertion (POST) and deletion (DELETE)
-func vectorHandler(w http.ResponseWriter, r *http.Request) {
+// Search the top N closest matches for several vectors at once
+func searchBatchHandler(w http.ResponseWriter, r *http.Request, name string) {
 	logRequest(r)
-	mutex.Lock()
-	defer mutex.Unlock()
+	label := indexLabel(name)
+	searchesTotal.WithLabelValues(label, "search_batch").Inc()
 
-	if indexInstance == nil {
-		http.Error(w, "Index not initialized", http.StatusNotFound)
-		log.Println("Request failed: Index not initialized")
+	var req SearchBatchRequest
+	if !decodeRequest(w, r, &req, "SearchBatch") || !resolveTopN(w, &req.TopN, "SearchBatch") ||
+		!checkBatchSize(w, len(req.Vectors), "SearchBatch") {
+		searchFailuresTotal.WithLabelValues(label, "search_batch").Inc()
 		return
 	}
 
+	ctx, cancel := searchContext(r.Context())
+	defer cancel()
+	ni, err := acquireIndex(ctx, name)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		slog.Warn("SearchBatch failed", "index", name, "error", err)
+		searchFailuresTotal.WithLabelValues(label, "search_batch").Inc()
+		return
+	}
+
+	var results [][]SearchResult
+	err = runSearch(ctx, ni, func(ctx context.Context) error {
+		start := time.Now()
+		matches, err := ni.index.SearchBatchContext(ctx, req.Vectors, req.Dims, req.TopN)
+		searchDuration.WithLabelValues(name, "search_batch").Observe(time.Since(start).Seconds())
+		if errors.Is(err, victor.ErrIndexEmpty) {
+			matches, err = make([][]victor.MatchResult, len(req.Vectors)), nil
+		}
+		if err != nil {
+			return err
+		}
+		results = make([][]SearchResult, len(matches))
+		for i := range matches {
+			results[i] = ni.translate(matches[i])
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Search failed: %v", err), errorStatus(err))
+		slog.Warn("SearchBatch failed", "index", name, "error", err)
+		searchFailuresTotal.WithLabelValues(label, "search_batch").Inc()
+		return
+	}
+
+	for i := range results {
+		searchResults.WithLabelValues(name, "search_batch").Observe(float64(len(results[i])))
+	}
+
+	slog.Debug("SearchBatch successful", "index", name, "queries", len(results))
+	writeJSON(w, r, http.StatusOK, Response{Message: "Search successful", Result: results})
+}
+
+// Report the configuration and vector count of an index
+func statsHandler(w http.ResponseWriter, r *http.Request, name string) {
+	logRequest(r)
+
+	ni, err := acquireIndex(r.Context(), name)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		slog.Warn("Stats failed", "index", name, "error", err)
+		return
+	}
+	defer ni.unlock()
+
+	stats, err := ni.index.Stats()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Stats failed: %v", err), http.StatusInternalServerError)
+		slog.Error("Stats failed", "index", name, "error", err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, Response{Message: "Stats retrieved successfully", Result: stats})
+}
+
+// Handles vector insertion (POST, ?upsert=true replaces an existing ID) and
+// deletion (DELETE). The request is read before the index is locked, so a
+// slow upload does not hold up other requests on the index.
+func vectorHandler(w http.ResponseWriter, r *http.Request, name string) {
+	logRequest(r)
+
 	switch r.Method {
 	case "POST":
 		// Insert vector
 		var req InsertRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid JSON input", http.StatusBadRequest)
-			log.Println("Insert failed: Invalid JSON input")
+		if !decodeRequest(w, r, &req, "Insert") {
 			return
 		}
 
-		err := indexInstance.Insert(req.ID, req.Vector)
+		ni, err := acquireIndex(r.Context(), name)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to insert vector: %v", err), http.StatusInternalServerError)
-			log.Println("Insert failed:", err)
+			http.Error(w, err.Error(), errorStatus(err))
+			slog.Warn("Insert failed", "index", name, "error", err)
 			return
 		}
+		defer ni.unlock()
 
-		log.Printf("Vector inserted: ID=%d\n", req.ID)
-		json.NewEncoder(w).Encode(Response{Message: "Vector inserted successfully"})
+		insert := ni.insert
+		if r.URL.Query().Get("upsert") == "true" {
+			insert = ni.upsert
+		}
+		err = insert(r.Context(), req.ID, req.Vector)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to insert vector: %v", err), errorStatus(err))
+			slog.Warn("Insert failed", "index", name, "error", err)
+			return
+		}
+
+		insertsTotal.WithLabelValues(name).Inc()
+		slog.Debug("Vector inserted", "index", name, "id", req.ID)
+		writeJSON(w, r, http.StatusOK, Response{Message: "Vector inserted successfully"})
 
 	case "DELETE":
 		// Delete vector
 		idStr := r.URL.Query().Get("id")
 		if idStr == "" {
 			http.Error(w, "Missing vector ID", http.StatusBadRequest)
-			log.Println("Delete failed: Missing vector ID")
+			slog.Warn("Delete failed: Missing vector ID", "index", name)
 			return
 		}
 
 		id, err := strconv.ParseUint(idStr, 10, 64)
 		if err != nil {
 			http.Error(w, "Invalid ID format", http.StatusBadRequest)
-			log.Println("Delete failed: Invalid ID format")
+			slog.Warn("Delete failed: Invalid ID format", "index", name)
 			return
 		}
 
-		err = indexInstance.Delete(id)
+		ni, err := acquireIndex(r.Context(), name)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to delete vector: %v", err), http.StatusInternalServerError)
-			log.Println("Delete failed:", err)
+			http.Error(w, err.Error(), errorStatus(err))
+			slog.Warn("Delete failed", "index", name, "error", err)
 			return
 		}
+		defer ni.unlock()
 
-		log.Printf("Vector deleted: ID=%d\n", id)
-		json.NewEncoder(w).Encode(Response{Message: "Vector deleted successfully"})
+		err = ni.delete(id)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to delete vector: %v", err), errorStatus(err))
+			slog.Warn("Delete failed", "index", name, "error", err)
+			return
+		}
+
+		deletesTotal.WithLabelValues(name).Inc()
+		slog.Debug("Vector deleted", "index", name, "id", id)
+		writeJSON(w, r, http.StatusOK, Response{Message: "Vector deleted successfully"})
 
 	default:
 		// Unsupported method
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		log.Println("Invalid HTTP method:", r.Method)
+		slog.Warn("Invalid HTTP method", "method", r.Method)
 	}
 }
 
+// Insert a stream of newline-delimited InsertRequest records as they arrive,
+// one record per line. A bad record is reported in the summary without
+// stopping the stream; a record over -max-body stops it and answers 413 with
+// what was inserted so far. The index is locked per record so searches are
+// not blocked meanwhile, and only each line, not the whole body, is subject
+// to -max-body.
+func bulkHandler(w http.ResponseWriter, r *http.Request, name string) {
+	logRequest(r)
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		slog.Warn("Invalid HTTP method", "method", r.Method)
+		return
+	}
+	upsert := r.URL.Query().Get("upsert") == "true"
+
+	var resp BulkResponse
+	status := http.StatusOK
+	fail := func(record int, id uint64, err error) {
+		resp.Failed++
+		resp.Errors = append(resp.Errors, BulkError{Record: record, ID: id, Error: err.Error()})
+	}
+
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 0, min(bufio.MaxScanTokenSize, maxBodyBytes)), int(maxBodyBytes))
+	record := 0
+	for scanner.Scan() {
+		record++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var req InsertRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			fail(record, req.ID, err)
+			continue
+		}
+
+		ni, err := acquireIndex(r.Context(), name)
+		if err != nil {
+			fail(record, req.ID, err)
+			status = errorStatus(err)
+			break
+		}
+		insert := ni.insert
+		if upsert {
+			insert = ni.upsert
+		}
+		err = insert(r.Context(), req.ID, req.Vector)
+		ni.unlock()
+		if err != nil {
+			fail(record, req.ID, err)
+			continue
+		}
+		resp.Inserted++
+		insertsTotal.WithLabelValues(name).Inc()
+	}
+	if err := scanner.Err(); err != nil && status == http.StatusOK {
+		status = http.StatusBadRequest
+		if errors.Is(err, bufio.ErrTooLong) {
+			err = fmt.Errorf("Record exceeds %d bytes", maxBodyBytes)
+			status = http.StatusRequestEntityTooLarge
+		}
+		fail(record+1, 0, err)
+	}
+
+	slog.Info("Bulk insert finished", "index", name, "inserted", resp.Inserted, "failed", resp.Failed)
+	writeJSON(w, r, status, Response{Message: "Bulk insert finished", Result: resp})
+}
+
 // Destroy the index
-func destroyIndexHandler(w http.ResponseWriter, r *http.Request) {
+func destroyIndexHandler(w http.ResponseWriter, r *http.Request, name string) {
 	logRequest(r)
-	mutex.Lock()
-	defer mutex.Unlock()
 
-	if indexInstance == nil {
+	indexesMutex.Lock()
+	ni := indexes[name]
+	delete(indexes, name)
+	indexesMutex.Unlock()
+
+	if ni == nil {
 		http.Error(w, "Index not initialized", http.StatusNotFound)
-		log.Println("Destroy failed: Index not initialized")
+		slog.Warn("Destroy failed: Index not initialized", "index", name)
 		return
 	}
 
-	indexInstance.DestroyIndex()
-	indexInstance = nil
-	log.Println("Index destroyed successfully")
-	json.NewEncoder(w).Encode(Response{Message: "Index destroyed successfully"})
+	ni.destroy()
+
+	slog.Info("Index destroyed successfully", "index", name)
+	writeJSON(w, r, http.StatusOK, Response{Message: "Index destroyed successfully"})
 }
 
-// Start the HTTP server
+// Liveness probe: always 200 while the process is serving. Probes are not
+// logged so they don't flood the request log.
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, StatusResponse{Status: "ok"})
+}
+
+// Readiness probe: 200 only when the index given by ?index= (or the default
+// index) exists. Only takes the registry read lock, never an index lock.
+func readyHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("index")
+	if name == "" {
+		name = defaultIndexName
+	}
+
+	indexesMutex.RLock()
+	_, exists := indexes[name]
+	indexesMutex.RUnlock()
+
+	if !exists {
+		writeJSON(w, r, http.StatusServiceUnavailable, StatusResponse{Status: "not ready", Index: name})
+		return
+	}
+	writeJSON(w, r, http.StatusOK, StatusResponse{Status: "ready", Index: name})
+}
+
+// Report the linked libvictor version and the one the bindings expect
+func versionHandler(w http.ResponseWriter, r *http.Request) {
+	logRequest(r)
+	writeJSON(w, r, http.StatusOK, VersionResponse{
+		Library:  victor.LibraryVersion(),
+		Bindings: victor.BindingsVersion(),
+	})
+}
+
+// Destroy every index in the registry
+func destroyAllIndexes() {
+	indexesMutex.Lock()
+	defer indexesMutex.Unlock()
+
+	for name, ni := range indexes {
+		ni.destroy()
+		delete(indexes, name)
+	}
+}
+
+// Dispatch to a subcommand. Without one (or with only flags) the server is
+// started, as before subcommands existed.
 func main() {
+	cmd, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "serve":
+		serve(args)
+	case "create":
+		createCommand(args)
+	case "insert":
+		insertCommand(args)
+	case "search":
+		searchCommand(args)
+	case "help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", cmd)
+		usage()
+		os.Exit(2)
+	}
+}
+
+// Print the list of subcommands
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: victor <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  serve    Start the HTTP server (default)")
+	fmt.Fprintln(os.Stderr, "  create   Create an index on a running server")
+	fmt.Fprintln(os.Stderr, "  insert   Insert vectors into an index on a running server")
+	fmt.Fprintln(os.Stderr, "  search   Search an index on a running server")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Run 'victor <command> -h' for the flags of a command.")
+	fmt.Fprintln(os.Stderr, "Client commands send $VICTOR_AUTH_TOKEN as a bearer token when it is set.")
+}
+
+// Start the HTTP server
+func serve(args []string) {
 	fmt.Println("Victor Cache Database v0.1")
 	fmt.Println("==========================")
 
 	// Command-line flags
-	addr := flag.String("addr", "localhost", "Listening address")
-	port := flag.String("port", "8080", "Listening port")
-	flag.Parse()
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", envOr("VICTOR_ADDR", "localhost"), "Listening address (overrides $VICTOR_ADDR)")
+	port := fs.String("port", envOr("VICTOR_PORT", "8080"), "Listening port (overrides $VICTOR_PORT)")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 10*time.Second, "Time to wait for in-flight requests on shutdown")
+	logLevel := fs.String("log-level", "info", "Log level: debug, info, warn or error")
+	maxBody := fs.Int64("max-body", maxBodyBytes, "Maximum request body size in bytes")
+	topN := fs.Int("default-top-n", defaultTopN, "Number of results when a search omits top_n")
+	maxN := fs.Int("max-top-n", maxTopN, "Largest top_n a search may request")
+	batch := fs.Int("max-batch", maxBatch, "Most vectors a search_batch request may hold")
+	authTokens := fs.String("auth-tokens", "", "Comma-separated bearer tokens required on every request but /health and /ready, /metrics included (overrides $VICTOR_AUTH_TOKENS; auth is off when empty)")
+	grpcAddr := fs.String("grpc-addr", "", "Also serve the gRPC API on this address (needs -tags grpc)")
+	corsOrigins := fs.String("cors-origins", "", "Comma-separated origins allowed by CORS, or \"*\" (CORS is off when empty)")
+	corsMethods := fs.String("cors-methods", "GET, POST, DELETE, OPTIONS", "Methods allowed in CORS preflight responses")
+	corsHeaders := fs.String("cors-headers", "Content-Type, Authorization", "Headers allowed in CORS preflight responses")
+	normCheck := fs.String("norm-check", "off", "Unit norm check on COSINE inserts: off, warn or strict (reject with 400)")
+	timeout := fs.Duration("search-timeout", 0, "Answer 504 when a search, including its wait for the index lock, takes longer than this (0 = no limit)")
+	noMatch := fs.String("no-match", noMatchEmpty, "Search reply when nothing matches: \"empty\" (200 with an empty result) or \"404\"")
+	fs.Parse(args)
+
+	// The tokens are read from the environment only after parsing, so that
+	// -h never prints them as the flag default
+	tokensSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "auth-tokens" {
+			tokensSet = true
+		}
+	})
+	if !tokensSet {
+		*authTokens = os.Getenv("VICTOR_AUTH_TOKENS")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+		fatal("Invalid -log-level value", "value", *logLevel)
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	if *noMatch != noMatchEmpty && *noMatch != noMatch404 {
+		fatal("Invalid -no-match value", "value", *noMatch, "valid", []string{noMatchEmpty, noMatch404})
+	}
+	noMatchMode = *noMatch
+	maxBodyBytes = *maxBody
+	if *maxN < 1 || *topN < 1 || *topN > *maxN {
+		fatal("Invalid top N limits", "default-top-n", *topN, "max-top-n", *maxN)
+	}
+	defaultTopN, maxTopN = *topN, *maxN
+	if *batch < 1 {
+		fatal("Invalid -max-batch value", "value", *batch)
+	}
+	maxBatch = *batch
+	mode, ok := normChecks[*normCheck]
+	if !ok {
+		fatal("Invalid -norm-check value", "value", *normCheck)
+	}
+	normCheckMode = mode
+	searchTimeout = *timeout
 
-	serverAddr := fmt.Sprintf("%s:%s", *addr, *port)
-	log.Printf("Starting Victor API server on %s\n", serverAddr)
+	if err := victor.CheckLibraryVersion(); err != nil {
+		fatal("Incompatible libvictor", "error", err)
+	}
+	slog.Info("Using libvictor", "version", victor.LibraryVersion())
 
-	// Define routes
-	http.HandleFunc("/", createIndexHandler)
-	http.HandleFunc("/index/vector", vectorHandler)
-	http.HandleFunc("/search", searchVectorHandler)
-	http.HandleFunc("/search_n", searchNVectorHandler)
-	http.HandleFunc("/index", destroyIndexHandler)
+	serverAddr := net.JoinHostPort(*addr, *port)
+	if isWildcard(*addr) && len(splitList(*authTokens)) == 0 {
+		slog.Warn("Listening on all interfaces without authentication; set -auth-tokens or VICTOR_AUTH_TOKENS", "addr", serverAddr)
+	}
+	slog.Info("Starting Victor API server", "addr", serverAddr)
+
+	// Define routes. The unnamed routes operate on the "default" index.
+	http.HandleFunc("/", withIndex(defaultIndexName, createIndexHandler))
+	http.HandleFunc("/index/vector", withIndex(defaultIndexName, vectorHandler))
+	http.HandleFunc("/bulk", withIndex(defaultIndexName, bulkHandler))
+	http.HandleFunc("/search", withIndex(defaultIndexName, searchVectorHandler))
+	http.HandleFunc("/search_n", withIndex(defaultIndexName, searchNVectorHandler))
+	http.HandleFunc("/search_batch", withIndex(defaultIndexName, searchBatchHandler))
+	http.HandleFunc("/stats", withIndex(defaultIndexName, statsHandler))
+	http.HandleFunc("/index", withIndex(defaultIndexName, destroyIndexHandler))
+	http.HandleFunc("/index/", namedIndexHandler)
+	http.HandleFunc("/health", healthHandler)
+	http.HandleFunc("/ready", readyHandler)
+	http.Handle("/metrics", promhttp.Handler())
+	http.HandleFunc("/version", versionHandler)
 
-	// Graceful shutdown
+	// CORS wraps auth so browsers can complete preflight without a token
+	var handler http.Handler = http.DefaultServeMux
+	if tokens := splitList(*authTokens); len(tokens) > 0 {
+		handler = withAuth(handler, tokens)
+		slog.Info("Authentication enabled", "tokens", len(tokens))
+	}
+	if origins := splitList(*corsOrigins); len(origins) > 0 {
+		handler = withCORS(handler, &corsConfig{origins: origins, methods: *corsMethods, headers: *corsHeaders})
+		slog.Info("CORS enabled", "origins", origins)
+	}
+
+	srv := &http.Server{Addr: serverAddr, Handler: handler}
 	go func() {
-		if err := http.ListenAndServe(serverAddr, nil); err != nil {
-			log.Fatalf("Server error: %v", err)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fatal("Server error", "error", err)
 		}
 	}()
 
+	stopGRPC := func() {}
+	if *grpcAddr != "" {
+		if serveGRPC == nil {
+			fatal("gRPC support not built in; rebuild with -tags grpc")
+		}
+		stop, err := serveGRPC(*grpcAddr, splitList(*authTokens))
+		if err != nil {
+			fatal("gRPC server error", "error", err)
+		}
+		stopGRPC = stop
+	}
+
 	// Handle SIGINT (Ctrl+C) and SIGTERM
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
 	<-sig
 
-	log.Println("Shutting down server...")
-	if indexInstance != nil {
-		indexInstance.DestroyIndex()
+	slog.Info("Shutting down server...")
+
+	// Graceful shutdown: stop accepting connections and let in-flight
+	// requests finish before the indexes they use are freed
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("Shutdown did not drain in time", "error", err)
 	}
-	log.Println("Server stopped.")
+	stopGRPC()
+
+	destroyAllIndexes()
+	slog.Info("Server stopped.")
 }
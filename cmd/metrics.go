@@ -0,0 +1,52 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus collectors exposed on /metrics, labeled by index name
+var (
+	insertsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "victor_inserts_total",
+		Help: "Number of vectors inserted.",
+	}, []string{"index"})
+
+	deletesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "victor_deletes_total",
+		Help: "Number of vectors deleted.",
+	}, []string{"index"})
+
+	searchesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "victor_searches_total",
+		Help: "Number of search requests.",
+	}, []string{"index", "endpoint"})
+
+	searchFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "victor_search_failures_total",
+		Help: "Number of search requests that failed.",
+	}, []string{"index", "endpoint"})
+
+	searchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "victor_search_duration_seconds",
+		Help:    "Time spent in the index search call.",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 2, 16),
+	}, []string{"index", "endpoint"})
+
+	searchResults = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "victor_search_results",
+		Help:    "Number of matches returned per search.",
+		Buckets: []float64{0, 1, 2, 5, 10, 20, 50, 100, 500, 1000},
+	}, []string{"index", "endpoint"})
+)
+
+// Label a metric with the index name only if that index exists, so requests
+// for arbitrary names cannot create unbounded label values
+func indexLabel(name string) string {
+	indexesMutex.RLock()
+	defer indexesMutex.RUnlock()
+	if _, exists := indexes[name]; !exists {
+		return "unknown"
+	}
+	return name
+}
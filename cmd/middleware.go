@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// CORS settings. Origins may contain "*" to allow any origin.
+type corsConfig struct {
+	origins []string
+	methods string
+	headers string
+}
+
+// Split a comma-separated flag value, dropping empty entries
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Return the value for Access-Control-Allow-Origin, or "" if the origin is
+// not allowed
+func (c *corsConfig) allowOrigin(origin string) string {
+	for _, o := range c.origins {
+		if o == "*" {
+			return "*"
+		}
+		if o == origin {
+			return origin
+		}
+	}
+	return ""
+}
+
+// Add CORS headers for allowed origins and answer preflight requests
+func withCORS(next http.Handler, c *corsConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := ""
+		if origin != "" {
+			allowed = c.allowOrigin(origin)
+		}
+		if allowed != "" {
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+			if allowed != "*" {
+				w.Header().Add("Vary", "Origin")
+			}
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if allowed != "" {
+				w.Header().Set("Access-Control-Allow-Methods", c.methods)
+				w.Header().Set("Access-Control-Allow-Headers", c.headers)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Check an Authorization header value of the form "Bearer <token>" against
+// the accepted tokens
+func validToken(header string, tokens []string) bool {
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found {
+		return false
+	}
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Paths served without a token, so load balancer probes keep working when
+// auth is on
+var publicPaths = map[string]bool{
+	"/health": true,
+	"/ready":  true,
+}
+
+// Require an "Authorization: Bearer <token>" header matching one of tokens on
+// every path but publicPaths
+func withAuth(next http.Handler, tokens []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] || validToken(r.Header.Get("Authorization"), tokens) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("WWW-Authenticate", `Bearer realm="victor"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		slog.Warn("Unauthorized request", "method", r.Method, "path", r.URL.Path)
+	})
+}
@@ -0,0 +1,114 @@
+package victor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Distance computes the distance between two vectors with the same metric
+// the index uses for the given method: the Euclidean distance for L2NORM and
+// the cosine similarity for COSINE (0 if either vector has zero magnitude).
+func Distance(method int, a, b []float32) (float32, error) {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0, ErrInvalidDimensions
+	}
+
+	switch method {
+	case L2NORM:
+		var sum float64
+		for i := range a {
+			diff := float64(a[i]) - float64(b[i])
+			sum += diff * diff
+		}
+		return float32(math.Sqrt(sum)), nil
+
+	case COSINE:
+		var dot, norm1, norm2 float64
+		for i := range a {
+			dot += float64(a[i]) * float64(b[i])
+			norm1 += float64(a[i]) * float64(a[i])
+			norm2 += float64(b[i]) * float64(b[i])
+		}
+		if norm1 == 0 || norm2 == 0 {
+			return 0, nil
+		}
+		return float32(dot / (math.Sqrt(norm1) * math.Sqrt(norm2))), nil
+	}
+	return 0, fmt.Errorf("Invalid method: %d", method)
+}
+
+// IsBetterMatch reports whether distance a is a better match than b for the
+// given method (lower for L2NORM, higher for COSINE)
+func IsBetterMatch(method int, a, b float32) bool {
+	if method == COSINE {
+		return a > b
+	}
+	return a < b
+}
+
+// Rerank scores the given results against the query under method and
+// returns them sorted best first. The index does not hand stored vectors
+// back, so the caller supplies them keyed by result ID; a result without a
+// vector yields ErrInvalidID.
+func Rerank(method int, query []float32, results []MatchResult, vectors map[int][]float32) ([]MatchResult, error) {
+	reranked := make([]MatchResult, len(results))
+	for i, r := range results {
+		vector, exists := vectors[r.ID]
+		if !exists {
+			return nil, fmt.Errorf("result %d: %w", r.ID, ErrInvalidID)
+		}
+		d, err := Distance(method, query, vector)
+		if err != nil {
+			return nil, err
+		}
+		reranked[i] = MatchResult{ID: r.ID, Distance: d, Similarity: Similarity(method, d)}
+	}
+
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return IsBetterMatch(method, reranked[i].Distance, reranked[j].Distance)
+	})
+	return reranked, nil
+}
+
+// Subspace returns the components of vector selected by mask. The mask must
+// be as long as the vector and select at least one dimension.
+func Subspace(vector []float32, mask []bool) ([]float32, error) {
+	if len(mask) != len(vector) {
+		return nil, ErrInvalidDimensions
+	}
+
+	out := make([]float32, 0, len(vector))
+	for i, keep := range mask {
+		if keep {
+			out = append(out, vector[i])
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrInvalidDimensions
+	}
+	return out, nil
+}
+
+// RerankSubspace is Rerank with the distance computed only over the
+// dimensions selected by mask, e.g. the first 128 of 384 for a coarse pass.
+// The C index always compares all dimensions, so this works on the
+// candidates a full SearchN returned.
+func RerankSubspace(method int, query []float32, mask []bool, results []MatchResult, vectors map[int][]float32) ([]MatchResult, error) {
+	q, err := Subspace(query, mask)
+	if err != nil {
+		return nil, err
+	}
+
+	projected := make(map[int][]float32, len(results))
+	for _, r := range results {
+		vector, exists := vectors[r.ID]
+		if !exists {
+			return nil, fmt.Errorf("result %d: %w", r.ID, ErrInvalidID)
+		}
+		if projected[r.ID], err = Subspace(vector, mask); err != nil {
+			return nil, fmt.Errorf("result %d: %w", r.ID, err)
+		}
+	}
+	return Rerank(method, q, results, projected)
+}
@@ -0,0 +1,92 @@
+package victor
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  int
+		a, b    []float32
+		want    float32
+		wantErr error
+	}{
+		{"L2NORM", L2NORM, []float32{0, 0}, []float32{3, 4}, 5, nil},
+		{"L2NORM identical", L2NORM, []float32{1, 2, 3}, []float32{1, 2, 3}, 0, nil},
+		{"COSINE parallel", COSINE, []float32{1, 1}, []float32{2, 2}, 1, nil},
+		{"COSINE orthogonal", COSINE, []float32{1, 0}, []float32{0, 1}, 0, nil},
+		{"COSINE opposite", COSINE, []float32{1, 0}, []float32{-3, 0}, -1, nil},
+		{"COSINE zero vector", COSINE, []float32{0, 0}, []float32{1, 0}, 0, nil},
+		{"unequal lengths", L2NORM, []float32{1, 2}, []float32{1, 2, 3}, 0, ErrInvalidDimensions},
+		{"empty", COSINE, []float32{}, []float32{}, 0, ErrInvalidDimensions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Distance(tt.method, tt.a, tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Distance: got error %v, want %v", err, tt.wantErr)
+			}
+			if math.Abs(float64(got-tt.want)) > 1e-6 {
+				t.Fatalf("Distance = %f, want %f", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Distance(-1, []float32{1}, []float32{1}); err == nil {
+		t.Fatalf("Distance with an invalid method: got nil error")
+	}
+}
+
+func TestRerank(t *testing.T) {
+	vectors := map[int][]float32{1: {5, 0}, 2: {1, 9}, 3: {1, 1}}
+	query := []float32{1, 0}
+	candidates := []MatchResult{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	tests := []struct {
+		name    string
+		method  int
+		mask    []bool
+		results []MatchResult
+		want    []int
+		wantErr error
+	}{
+		{"L2NORM", L2NORM, nil, candidates, []int{3, 1, 2}, nil},
+		{"COSINE", COSINE, nil, candidates, []int{1, 3, 2}, nil},
+		{"subspace first dimension", L2NORM, []bool{true, false}, candidates, []int{2, 3, 1}, nil},
+		{"missing vector", L2NORM, nil, []MatchResult{{ID: 1}, {ID: 4}}, nil, ErrInvalidID},
+		{"subspace missing vector", L2NORM, []bool{true, false}, []MatchResult{{ID: 4}}, nil, ErrInvalidID},
+		{"subspace short mask", L2NORM, []bool{true}, candidates, nil, ErrInvalidDimensions},
+		{"subspace empty mask", L2NORM, []bool{false, false}, candidates, nil, ErrInvalidDimensions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []MatchResult
+			var err error
+			if tt.mask == nil {
+				got, err = Rerank(tt.method, query, tt.results, vectors)
+			} else {
+				got, err = RerankSubspace(tt.method, query, tt.mask, tt.results, vectors)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			ids := resultIDs(got)
+			if len(ids) != len(tt.want) {
+				t.Fatalf("IDs = %v, want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("IDs = %v, want %v", ids, tt.want)
+				}
+			}
+			for _, r := range got {
+				if r.Similarity != Similarity(tt.method, r.Distance) {
+					t.Fatalf("result %d: Similarity = %f, want %f", r.ID, r.Similarity, Similarity(tt.method, r.Distance))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+//go:build cgo
+
+package victor
+
+/*
+#cgo LDFLAGS: -L./lib -lvictor
+#include "lib/index.h"
+#include "lib/types.h"
+#include "lib/vector.h"
+#include "lib/iflat_utils.h"
+#include <stdlib.h>
+
+static const char *bindings_version(void) {
+	return VICTOR_VERSION;
+}
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// handle holds the C index
+type handle struct {
+	ptr *C.Index
+}
+
+// toError converts a C error code to a Go error
+func toError(code C.int) error {
+	if code == C.int(SUCCESS) {
+		return nil
+	}
+	if err, exists := codeErrors[ErrorCode(code)]; exists {
+		return err
+	}
+	return fmt.Errorf("Unknown error code: %d", code)
+}
+
+// LibraryVersion returns the version of the linked libvictor
+func LibraryVersion() string {
+	return C.GoString(C.victor_version())
+}
+
+// BindingsVersion returns the libvictor version these bindings were built against
+func BindingsVersion() string {
+	return C.GoString(C.bindings_version())
+}
+
+func allocHandle(indexType, method int, dims uint16) (*handle, error) {
+	ptr := C.alloc_index(C.int(indexType), C.int(method), C.uint16_t(dims))
+	if ptr == nil {
+		return nil, fmt.Errorf("Failed to allocate index")
+	}
+	return &handle{ptr: ptr}, nil
+}
+
+func (h *handle) initialized() bool {
+	return h != nil && h.ptr != nil
+}
+
+func (idx *Index) insert(id uint64, vector []float32) error {
+	cVector := (*C.float)(unsafe.Pointer(&vector[0]))
+	return toError(C.insert(idx.ptr, C.uint64_t(id), cVector, C.uint16_t(len(vector))))
+}
+
+func (idx *Index) search(vector []float32) (*MatchResult, error) {
+	var cResult C.MatchResult
+	cVector := (*C.float)(unsafe.Pointer(&vector[0]))
+	err := C.search(idx.ptr, cVector, C.uint16_t(len(vector)), &cResult)
+	if e := toError(err); e != nil {
+		return nil, e
+	}
+
+	return &MatchResult{
+		ID:         int(cResult.id),
+		Distance:   float32(cResult.distance),
+		Similarity: Similarity(idx.method, float32(cResult.distance)),
+	}, nil
+}
+
+func (idx *Index) searchN(vector []float32, n int) ([]MatchResult, error) {
+	// flat_search_n_mp is not implemented in libvictor yet
+	if idx.indexType == FLAT_INDEX_MP {
+		return nil, ErrNotSupported
+	}
+
+	// Convertir el vector Go a un puntero C
+	cVector := (*C.float)(unsafe.Pointer(&vector[0]))
+
+	// Crear un buffer en C para almacenar los resultados
+	var cResults *C.MatchResult
+
+	// Llamar a la función C
+	err := C.search_n(idx.ptr, cVector, C.uint16_t(len(vector)), &cResults, C.int(n))
+	if e := toError(err); e != nil {
+		return nil, e
+	}
+
+	// Convertir los resultados de C a un slice de Go. El buffer siempre tiene
+	// n entradas, pero si el índice tiene menos vectores las últimas quedan
+	// vacías (EMPTY_MATCH_ID) y se descartan.
+	cResultsSlice := unsafe.Slice(cResults, n)
+	results := make([]MatchResult, 0, n)
+	for i := 0; i < n && cResultsSlice[i].id != C.EMPTY_MATCH_ID; i++ {
+		results = append(results, MatchResult{
+			ID:         int(cResultsSlice[i].id),
+			Distance:   float32(cResultsSlice[i].distance),
+			Similarity: Similarity(idx.method, float32(cResultsSlice[i].distance)),
+		})
+	}
+
+	C.free(unsafe.Pointer(cResults))
+	return results, nil
+}
+
+// bytesPerVector is the size of a stored vector in the C flat indexes: a
+// Vector (ID plus dims padded to a multiple of 4) and its list node
+func (idx *Index) bytesPerVector() uint64 {
+	dims := (uint64(idx.dims) + 3) &^ 3
+	return uint64(C.sizeof_Vector) + dims*uint64(C.sizeof_float) + uint64(C.sizeof_INodeFlat)
+}
+
+func (h *handle) size() (uint64, error) {
+	var n C.uint64_t
+	if err := toError(C.size(h.ptr, &n)); err != nil {
+		return 0, err
+	}
+	return uint64(n), nil
+}
+
+func (h *handle) delete(id uint64) error {
+	return toError(C.delete(h.ptr, C.uint64_t(id)))
+}
+
+func (h *handle) destroy() {
+	C.destroy_index(&h.ptr)
+	h.ptr = nil
+}
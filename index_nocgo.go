@@ -0,0 +1,130 @@
+//go:build !cgo
+
+package victor
+
+import (
+	"sort"
+	"sync"
+	"unsafe"
+)
+
+// version reported by the pure-Go index; keep in sync with VICTOR_VERSION in
+// lib/index.h
+const version = "0.2.0"
+
+// entry is a stored vector
+type entry struct {
+	id     uint64
+	vector []float32
+}
+
+// handle holds the vectors of the pure-Go index. Every search is exhaustive,
+// so FLAT_INDEX and FLAT_INDEX_MP behave the same.
+type handle struct {
+	rwlock  sync.RWMutex
+	ready   bool
+	entries []entry
+	pos     map[uint64]int // id -> index in entries
+}
+
+// LibraryVersion returns the version of the pure-Go index, since no
+// libvictor is linked without cgo
+func LibraryVersion() string {
+	return version
+}
+
+// BindingsVersion returns the version of the pure-Go index
+func BindingsVersion() string {
+	return version
+}
+
+func allocHandle(indexType, method int, dims uint16) (*handle, error) {
+	return &handle{ready: true, pos: make(map[uint64]int)}, nil
+}
+
+func (h *handle) initialized() bool {
+	return h != nil && h.ready
+}
+
+func (idx *Index) insert(id uint64, vector []float32) error {
+	v := make([]float32, len(vector))
+	copy(v, vector)
+
+	idx.rwlock.Lock()
+	defer idx.rwlock.Unlock()
+	idx.pos[id] = len(idx.entries)
+	idx.entries = append(idx.entries, entry{id: id, vector: v})
+	return nil
+}
+
+func (idx *Index) search(vector []float32) (*MatchResult, error) {
+	results, err := idx.searchN(vector, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &results[0], nil
+}
+
+// searchN scores every stored vector and keeps the n best, like the C flat
+// index
+func (idx *Index) searchN(vector []float32, n int) ([]MatchResult, error) {
+	idx.rwlock.RLock()
+	defer idx.rwlock.RUnlock()
+
+	if len(idx.entries) == 0 {
+		return nil, ErrIndexEmpty
+	}
+
+	results := make([]MatchResult, 0, len(idx.entries))
+	for _, e := range idx.entries {
+		d, err := Distance(idx.method, vector, e.vector)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, MatchResult{ID: int(e.id), Distance: d, Similarity: Similarity(idx.method, d)})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return IsBetterMatch(idx.method, results[i].Distance, results[j].Distance)
+	})
+	if len(results) > n {
+		results = results[:n]
+	}
+	return results, nil
+}
+
+// bytesPerVector is the size of a stored vector in the pure-Go index: the
+// vector data, its entry and its position map slot
+func (idx *Index) bytesPerVector() uint64 {
+	return uint64(idx.dims)*4 + uint64(unsafe.Sizeof(entry{})) + 16
+}
+
+func (h *handle) size() (uint64, error) {
+	h.rwlock.RLock()
+	defer h.rwlock.RUnlock()
+	return uint64(len(h.entries)), nil
+}
+
+func (h *handle) delete(id uint64) error {
+	h.rwlock.Lock()
+	defer h.rwlock.Unlock()
+
+	i, exists := h.pos[id]
+	if !exists {
+		return ErrInvalidID
+	}
+	last := len(h.entries) - 1
+	h.entries[i] = h.entries[last]
+	h.pos[h.entries[i].id] = i
+	h.entries = h.entries[:last]
+	delete(h.pos, id)
+	return nil
+}
+
+func (h *handle) destroy() {
+	h.rwlock.Lock()
+	defer h.rwlock.Unlock()
+	h.ready = false
+	h.entries = nil
+	h.pos = nil
+}
@@ -102,7 +102,7 @@ static void shift_right_mr(MatchResult *result, int len) {
  * comparison method. The function updates the MatchResult structure with the best match found.
  *
  * Steps:
- * 1. Initialize the result with the worst possible match value.
+ * 1. Initialize the result as empty (EMPTY_MATCH_ID) with the worst possible match value.
  * 2. Iterate through each node in the linked list.
  * 3. Compute the distance between the query vector and the current node's vector.
  * 4. If the computed distance is better than the current best match, update the result.
@@ -117,11 +117,11 @@ static void shift_right_mr(MatchResult *result, int len) {
 void flat_linear_search(INodeFlat *current, float32_t *v, uint16_t dims_aligned, MatchResult *result, CmpMethod *cmp) {
     float32_t distance;
     result->distance = cmp->worst_match_value;
-    result->id = 0;
+    result->id = EMPTY_MATCH_ID;
 
     while (current) {
         distance = cmp->compare_vectors(current->vector->vector, v, dims_aligned);
-        if (cmp->is_better_match(distance, result->distance)) {
+        if (result->id == EMPTY_MATCH_ID || cmp->is_better_match(distance, result->distance)) {
             result->id = current->vector->id;
             result->distance = distance;
         }
@@ -138,7 +138,7 @@ void flat_linear_search(INodeFlat *current, float32_t *v, uint16_t dims_aligned,
  * are stored in a sorted array, ensuring that the closest matches appear first.
  *
  * Steps:
- * 1. Initialize the top-N results with the worst possible match values.
+ * 1. Initialize the top-N results as empty (EMPTY_MATCH_ID) with the worst possible match values.
  * 2. Iterate through the linked list of vectors.
  * 3. Compute the distance between the query vector and each node's vector.
  * 4. If the computed distance is among the top-N best matches:
@@ -146,6 +146,9 @@ void flat_linear_search(INodeFlat *current, float32_t *v, uint16_t dims_aligned,
  *    b. Insert the new match in the correct position.
  * 5. Continue until all elements have been checked.
  *
+ * Slots left empty (fewer than N vectors) keep EMPTY_MATCH_ID, and the filled
+ * slots always come first.
+ *
  * @param current      - Pointer to the head of the linked list of INodeFlat.
  * @param v            - Pointer to the query vector.
  * @param dims_aligned - Number of aligned dimensions in the vector.
@@ -158,13 +161,14 @@ void flat_linear_search_n(INodeFlat *current, float32_t *v, uint16_t dims_aligne
     int i, k;
     for (i = 0; i < n; i++) {
         result[i].distance = cmp->worst_match_value;
-        result[i].id = 0;
+        result[i].id = EMPTY_MATCH_ID;
     }
     while (current) {
         distance = cmp->compare_vectors(current->vector->vector, v, dims_aligned);
         for (k = 0; k < n; k++) {
-            if (cmp->is_better_match(distance, result[k].distance)) {
-                shift_right_mr(&result[k], n - k - 1);
+            // Empty slots take any vector, even one at the worst possible value
+            if (result[k].id == EMPTY_MATCH_ID || cmp->is_better_match(distance, result[k].distance)) {
+                shift_right_mr(&result[k], n - k);
                 result[k].distance = distance;
                 result[k].id = current->vector->id;
                 break;
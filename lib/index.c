@@ -66,6 +66,22 @@ int delete(Index *index, uint64_t id) {
     return index->delete(index->data, id);
 }
 
+int size(Index *index, uint64_t *sz) {
+    if (!index || !index->data || !index->size)
+        return INVALID_INIT;
+    return index->size(index->data, sz);
+}
+
+/*
+ * Returns the version the library was built with (VICTOR_VERSION).
+ *
+ * Bindings compare it against the VICTOR_VERSION of the headers they were
+ * compiled with to detect an incompatible shared library at runtime.
+ */
+const char *victor_version(void) {
+    return VICTOR_VERSION;
+}
+
 /*
  * Destroys and deallocates an index.
  *
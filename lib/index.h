@@ -29,6 +29,12 @@
 
 #include "types.h"
 
+/*
+ * Library version. Bumped whenever the public structures or function
+ * signatures change, so bindings can detect a mismatched libvictor.
+ */
+#define VICTOR_VERSION "0.2.0"
+
 #define FLAT_INDEX    0x00
 #define FLAT_INDEX_MP 0x01
 /**
@@ -79,6 +85,14 @@ typedef struct {
      */
     int (*delete)(void *, uint64_t);
 
+    /**
+     * Returns the number of vectors stored in the index.
+     * @param data The specific index data structure.
+     * @param size Output, the number of stored vectors.
+     * @return 0 if successful, or -1 on error.
+     */
+    int (*size)(void *, uint64_t *);
+
     int (*_release)(void **);
 
 } Index;
@@ -91,8 +105,10 @@ extern int search_n(Index *index, float32_t *vector, uint16_t dims, MatchResult
 extern int search(Index *index, float32_t *vector, uint16_t dims, MatchResult *result);
 extern int insert(Index *index, uint64_t id, float32_t *vector, uint16_t dims);
 extern int delete(Index *index, uint64_t id);
+extern int size(Index *index, uint64_t *sz);
 
 extern Index *alloc_index(int type, int method, uint16_t dims);
+extern const char *victor_version(void);
 extern int destroy_index(Index **index);
 
 #endif // __INDEX_H
@@ -351,6 +351,31 @@ static int flat_insert(void *index, uint64_t id, float32_t *vector, uint16_t dim
 }
 
 
+/*
+ * flat_size - Returns the number of vectors stored in the flat index.
+ *
+ * @param index - Pointer to the flat index (`IndexFlat`).
+ * @param size  - Output, number of stored vectors.
+ *
+ * @return SUCCESS on success.
+ *         INVALID_INDEX if the index pointer is NULL.
+ *         INVALID_RESULT if the size pointer is NULL.
+ */
+static int flat_size(void *index, uint64_t *size) {
+    IndexFlat *ptr = (IndexFlat *)index;
+
+    if (index == NULL)
+        return INVALID_INDEX;
+    if (size == NULL)
+        return INVALID_RESULT;
+
+    pthread_rwlock_rdlock(&ptr->rwlock);
+    *size = ptr->elements;
+    pthread_rwlock_unlock(&ptr->rwlock);
+    return SUCCESS;
+}
+
+
 /*
  * flat_release - Releases all resources associated with a flat index.
  *
@@ -410,6 +435,7 @@ int flat_index(Index *idx, int method, uint16_t dims) {
     idx->search_n = flat_search_n;
     idx->insert   = flat_insert;
     idx->delete   = flat_delete;
+    idx->size     = flat_size;
     idx->_release = flat_release;
 
     return SUCCESS;
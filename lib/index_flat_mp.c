@@ -100,7 +100,14 @@ static IndexFlatMp *flat_mp_init(int method, uint16_t dims) {
     }
     index->rr = 0;
     index->threads = sysconf(_SC_NPROCESSORS_ONLN) / 2;
+    // Single-core hosts (or a failed sysconf) would leave no lists at all
+    if (index->threads < 1)
+        index->threads = 1;
     index->heads = calloc(index->threads, sizeof(INodeFlat *));
+    if (index->heads == NULL) {
+        free_mem(index);
+        return NULL;
+    }
     index->elements = 0;
     index->dims = dims;
     index->dims_aligned = ALIGN_DIMS(dims);
@@ -266,7 +273,7 @@ static int flat_search_mp(void *index, float32_t *vector, uint16_t dims, MatchRe
 
     // Initialize the result with the worst possible match value
     result->distance = idx->cmp->worst_match_value;
-    result->id = 0;
+    result->id = EMPTY_MATCH_ID;
 
     // Acquire a read lock to prevent modifications while searching
     pthread_rwlock_rdlock(&idx->rwlock);
@@ -288,8 +295,10 @@ static int flat_search_mp(void *index, float32_t *vector, uint16_t dims, MatchRe
     for (i = 0; i < idx->threads; i++) {
         pthread_join(data[i].thread, NULL);
 
-        // Compare results from all threads and keep the best match
-        if (idx->cmp->is_better_match(data[i].result->distance, result->distance)) {
+        // Compare results from all threads and keep the best match, skipping
+        // threads whose list was empty
+        if (data[i].result->id != EMPTY_MATCH_ID &&
+            (result->id == EMPTY_MATCH_ID || idx->cmp->is_better_match(data[i].result->distance, result->distance))) {
             result->id = data[i].result->id;
             result->distance = data[i].result->distance;
         }
@@ -375,6 +384,31 @@ static int flat_insert_mp(void *index, uint64_t id, float32_t *vector, uint16_t
 }
 
 
+/*
+ * flat_size - Returns the number of vectors stored in the flat index.
+ *
+ * @param index - Pointer to the flat index (`IndexFlatMp`).
+ * @param size  - Output, number of stored vectors.
+ *
+ * @return SUCCESS on success.
+ *         INVALID_INDEX if the index pointer is NULL.
+ *         INVALID_RESULT if the size pointer is NULL.
+ */
+static int flat_size_mp(void *index, uint64_t *size) {
+    IndexFlatMp *ptr = (IndexFlatMp *)index;
+
+    if (index == NULL)
+        return INVALID_INDEX;
+    if (size == NULL)
+        return INVALID_RESULT;
+
+    pthread_rwlock_rdlock(&ptr->rwlock);
+    *size = ptr->elements;
+    pthread_rwlock_unlock(&ptr->rwlock);
+    return SUCCESS;
+}
+
+
 /*
  * flat_release - Releases all resources associated with a flat index.
  *
@@ -438,6 +472,7 @@ int flat_index_mp(Index *idx, int method, uint16_t dims) {
     idx->search_n = flat_search_n_mp;
     idx->insert   = flat_insert_mp;
     idx->delete   = flat_delete_mp;
+    idx->size     = flat_size_mp;
     idx->_release = flat_release_mp;
 
     return SUCCESS;
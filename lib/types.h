@@ -34,6 +34,9 @@ typedef struct {
     float32_t distance;
 } MatchResult;
 
+// ID of a MatchResult slot that holds no vector
+#define EMPTY_MATCH_ID -1
+
 
 typedef enum {
     SUCCESS,
@@ -0,0 +1,7 @@
+// Package proto holds the gRPC API definition. The generated victorpb
+// package, which the server uses when built with -tags grpc, is checked in;
+// run go generate here (needs protoc, protoc-gen-go and protoc-gen-go-grpc)
+// to regenerate it after editing victor.proto.
+package proto
+
+//go:generate protoc --go_out=.. --go_opt=module=victor --go-grpc_out=.. --go-grpc_opt=module=victor victor.proto
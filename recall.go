@@ -0,0 +1,70 @@
+package victor
+
+import (
+	"fmt"
+	"sort"
+)
+
+// BruteForceSearchN returns the n best matches for the query among the given
+// vectors, keyed by ID, by scoring every one of them under method. It is
+// the exact reference used by Recall.
+func BruteForceSearchN(vectors map[int][]float32, query []float32, method, n int) ([]MatchResult, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid number of results: %d", n)
+	}
+
+	// Sorted IDs keep ties in a stable order between runs
+	candidates := make([]MatchResult, 0, len(vectors))
+	for id := range vectors {
+		candidates = append(candidates, MatchResult{ID: id})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+
+	results, err := Rerank(method, query, candidates, vectors)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) > n {
+		results = results[:n]
+	}
+	return results, nil
+}
+
+// Recall measures recall@n of idx against BruteForceSearchN: for each query,
+// the fraction of the exact top n that SearchN also returned, averaged over
+// all queries. vectors must hold what was inserted into idx, keyed by ID.
+func Recall(idx *Index, vectors map[int][]float32, queries [][]float32, n int) (float64, error) {
+	if len(queries) == 0 {
+		return 0, ErrInvalidVector
+	}
+
+	var total float64
+	for i, query := range queries {
+		exact, err := BruteForceSearchN(vectors, query, idx.method, n)
+		if err != nil {
+			return 0, fmt.Errorf("query %d: %w", i, err)
+		}
+		if len(exact) == 0 {
+			total++
+			continue
+		}
+
+		found, err := idx.SearchN(query, len(query), n)
+		if err != nil {
+			return 0, fmt.Errorf("query %d: %w", i, err)
+		}
+		got := make(map[int]struct{}, len(found))
+		for _, r := range found {
+			got[r.ID] = struct{}{}
+		}
+
+		hits := 0
+		for _, r := range exact {
+			if _, ok := got[r.ID]; ok {
+				hits++
+			}
+		}
+		total += float64(hits) / float64(len(exact))
+	}
+	return total / float64(len(queries)), nil
+}
@@ -0,0 +1,65 @@
+package victor
+
+import "testing"
+
+func TestBruteForceSearchN(t *testing.T) {
+	vectors := map[int][]float32{1: {0, 0}, 2: {3, 4}, 3: {1, 0}}
+
+	tests := []struct {
+		name   string
+		method int
+		n      int
+		want   []int
+	}{
+		{"L2NORM", L2NORM, 2, []int{3, 1}},
+		{"L2NORM n above size", L2NORM, 5, []int{3, 1, 2}},
+		{"COSINE", COSINE, 1, []int{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := BruteForceSearchN(vectors, []float32{1, 0}, tt.method, tt.n)
+			if err != nil {
+				t.Fatalf("BruteForceSearchN: %v", err)
+			}
+			got := resultIDs(results)
+			if len(got) != len(tt.want) {
+				t.Fatalf("BruteForceSearchN IDs = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("BruteForceSearchN IDs = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if _, err := BruteForceSearchN(vectors, []float32{1, 0}, L2NORM, 0); err == nil {
+		t.Fatalf("BruteForceSearchN with n 0: got nil error")
+	}
+}
+
+func TestRecallFlatIndex(t *testing.T) {
+	inserted := [][]float32{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {0.6, 0.8, 0}, {0, 0.6, 0.8}}
+	vectors := make(map[int][]float32, len(inserted))
+	for i, v := range inserted {
+		vectors[i+1] = v
+	}
+	queries := [][]float32{{1, 0.1, 0}, {0, 0.7, 0.6}, {0.2, 0.2, 0.9}}
+
+	// FLAT_INDEX is exhaustive, so it must find the exact top n every time
+	for _, method := range []int{L2NORM, COSINE} {
+		idx := newTestIndex(t, method, 3, inserted...)
+		recall, err := Recall(idx, vectors, queries, 2)
+		if err != nil {
+			t.Fatalf("Recall(method %d): %v", method, err)
+		}
+		if recall != 1 {
+			t.Errorf("Recall(method %d) = %f, want 1", method, recall)
+		}
+	}
+
+	idx := newTestIndex(t, L2NORM, 3, inserted...)
+	if _, err := Recall(idx, vectors, nil, 2); err != ErrInvalidVector {
+		t.Errorf("Recall without queries: got %v, want %v", err, ErrInvalidVector)
+	}
+}
@@ -1,15 +1,16 @@
+// Package victor wraps libvictor, an in-memory vector index written in C.
+// When cgo is not available (CGO_ENABLED=0), a pure-Go exhaustive index with
+// the same API and distance semantics is used instead.
 package victor
 
-/*
-#cgo LDFLAGS: -L./lib -lvictor
-#include "lib/index.h"
-#include "lib/types.h"
-#include <stdlib.h>
-*/
-import "C"
 import (
+	"context"
+	"errors"
 	"fmt"
-	"unsafe"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
 )
 
 // ErrorCode maps C error codes to Go
@@ -27,129 +28,530 @@ const (
 	SYSTEM_ERROR
 )
 
-// errorMessages maps error codes to human-readable messages
-var errorMessages = map[ErrorCode]string{
-	SUCCESS:            "Success",
-	INVALID_INIT:       "Invalid initialization",
-	INVALID_INDEX:      "Invalid index",
-	INVALID_VECTOR:     "Invalid vector",
-	INVALID_RESULT:     "Invalid result",
-	INVALID_DIMENSIONS: "Invalid dimensions",
-	INVALID_ID:         "Invalid ID",
-	INDEX_EMPTY:        "Index is empty",
-	SYSTEM_ERROR:       "System error",
+// Index types, as defined in lib/index.h
+const (
+	FLAT_INDEX    = 0x00
+	FLAT_INDEX_MP = 0x01
+)
+
+// Distance methods, as defined in lib/method.h
+const (
+	L2NORM = 0x00
+	COSINE = 0x01
+)
+
+// Norm check modes for vectors inserted into a COSINE index, see SetNormCheck
+const (
+	NormCheckOff    = iota // no check (default)
+	NormCheckWarn          // log a warning and insert anyway
+	NormCheckStrict        // reject the vector with ErrNotNormalized
+)
+
+// DefaultNormEpsilon is the tolerance on |norm - 1| used by SetNormCheck
+// when none is given
+const DefaultNormEpsilon = 1e-3
+
+// MaxID is the largest vector ID an index accepts. The C index reports
+// match IDs as a C int and marks empty result slots with -1, so larger IDs
+// could not be told apart from them.
+const MaxID = math.MaxInt32
+
+// IndexTypes maps the supported index types to their names
+var IndexTypes = map[int]string{
+	FLAT_INDEX:    "FLAT_INDEX",
+	FLAT_INDEX_MP: "FLAT_INDEX_MP",
 }
 
-// toError converts a C error code to a Go error
-func toError(code C.int) error {
-	if code == C.int(SUCCESS) {
-		return nil
-	}
-	if msg, exists := errorMessages[ErrorCode(code)]; exists {
-		return fmt.Errorf(msg)
-	}
-	return fmt.Errorf("Unknown error code: %d", code)
+// Methods maps the supported distance methods to their names
+var Methods = map[int]string{
+	L2NORM: "L2NORM",
+	COSINE: "COSINE",
+}
+
+// Sentinel errors returned for the C error codes, shared by the pure-Go index
+var (
+	ErrInvalidInit       = errors.New("Invalid initialization")
+	ErrInvalidIndex      = errors.New("Invalid index")
+	ErrInvalidVector     = errors.New("Invalid vector")
+	ErrInvalidResult     = errors.New("Invalid result")
+	ErrInvalidDimensions = errors.New("Invalid dimensions")
+	ErrInvalidID         = errors.New("Invalid ID")
+	ErrIndexEmpty        = errors.New("Index is empty") // no vectors inserted yet
+	ErrSystem            = errors.New("System error")
+	ErrDuplicate         = errors.New("Duplicated ID")
+	ErrNotNormalized     = errors.New("Vector is not normalized")
+	ErrNotSupported      = errors.New("Not supported by this index type")
+)
+
+// codeErrors maps error codes to their sentinel errors
+var codeErrors = map[ErrorCode]error{
+	INVALID_INIT:       ErrInvalidInit,
+	INVALID_INDEX:      ErrInvalidIndex,
+	INVALID_VECTOR:     ErrInvalidVector,
+	INVALID_RESULT:     ErrInvalidResult,
+	INVALID_DIMENSIONS: ErrInvalidDimensions,
+	INVALID_ID:         ErrInvalidID,
+	INDEX_EMPTY:        ErrIndexEmpty,
+	SYSTEM_ERROR:       ErrSystem,
 }
 
 // MatchResult represents a search result in Go
 type MatchResult struct {
-	ID       int     `json:"id"`
-	Distance float32 `json:"distance"`
+	ID         int     `json:"id"`
+	Distance   float32 `json:"distance"`
+	Similarity float32 `json:"similarity"`
+}
+
+// Similarity converts a distance returned by the given method into a score
+// where higher means more similar. COSINE already returns the cosine
+// similarity (-1..1) and is passed through; L2NORM distances are mapped to
+// 1/(1+d), which is 1 for identical vectors and tends to 0.
+func Similarity(method int, distance float32) float32 {
+	switch method {
+	case COSINE:
+		return distance
+	case L2NORM:
+		return float32(1 / (1 + float64(distance)))
+	}
+	return 0
+}
+
+// Normalize returns a copy of vector scaled to unit L2 norm. A zero vector
+// is returned unchanged.
+func Normalize(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+
+	out := make([]float32, len(vector))
+	if sum == 0 {
+		copy(out, vector)
+		return out
+	}
+
+	norm := math.Sqrt(sum)
+	for i, v := range vector {
+		out[i] = float32(float64(v) / norm)
+	}
+	return out
 }
 
 // Index represents an index structure in Go
 type Index struct {
-	ptr *C.Index
+	*handle // backend state: the C index, or the pure-Go vectors without cgo
+
+	indexType int
+	method    int
+	dims      uint16
+
+	// IDs currently stored, since the C index accepts duplicates. Inserts and
+	// deletes are serialized on mutex.
+	mutex sync.Mutex
+	ids   map[uint64]struct{}
+
+	// Unit norm check on insert, COSINE only
+	normCheck   int
+	normEpsilon float64
+}
+
+// SetNormCheck configures how Insert treats vectors whose L2 norm is not
+// within epsilon of 1 on a COSINE index: ignore them (NormCheckOff), log a
+// warning (NormCheckWarn) or reject them (NormCheckStrict). An epsilon <= 0
+// selects DefaultNormEpsilon. It has no effect on L2NORM indexes.
+func (idx *Index) SetNormCheck(mode int, epsilon float64) error {
+	if mode < NormCheckOff || mode > NormCheckStrict {
+		return fmt.Errorf("Invalid norm check mode: %d", mode)
+	}
+	if epsilon <= 0 {
+		epsilon = DefaultNormEpsilon
+	}
+
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	idx.normCheck = mode
+	idx.normEpsilon = epsilon
+	return nil
+}
+
+// unitNorm returns the L2 norm of vector and whether it is within the norm
+// check epsilon of 1. Vectors always pass on L2NORM indexes.
+func (idx *Index) unitNorm(vector []float32) (float64, bool) {
+	if idx.method != COSINE {
+		return 0, true
+	}
+
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	norm := math.Sqrt(sum)
+	return norm, math.Abs(norm-1) <= idx.normEpsilon
+}
+
+// validate runs the checks Insert does before touching the backend. The
+// caller must hold idx.mutex.
+func (idx *Index) validate(id uint64, vector []float32) error {
+	if len(vector) == 0 {
+		return ErrInvalidVector
+	}
+	if len(vector) != int(idx.dims) {
+		return ErrInvalidDimensions
+	}
+	if id > MaxID {
+		return fmt.Errorf("%w: %d exceeds %d", ErrInvalidID, id, MaxID)
+	}
+	if _, exists := idx.ids[id]; exists {
+		return ErrDuplicate
+	}
+	if idx.normCheck == NormCheckStrict {
+		if norm, ok := idx.unitNorm(vector); !ok {
+			return fmt.Errorf("%w: norm %.4f", ErrNotNormalized, norm)
+		}
+	}
+	return nil
+}
+
+// Validate reports whether Insert would accept the vector under the given ID,
+// running the same checks (vector size, ID range, duplicate ID and the strict
+// norm check) without inserting anything. An insert of the same ID made after
+// Validate returns can still make the later Insert fail.
+func (idx *Index) Validate(id uint64, vector []float32) error {
+	if !idx.initialized() {
+		return fmt.Errorf("Index not initialized")
+	}
+
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	return idx.validate(id, vector)
+}
+
+// CheckLibraryVersion fails if the linked libvictor doesn't match the headers
+// the bindings were built against, since struct layouts may differ
+func CheckLibraryVersion() error {
+	if lib, bindings := LibraryVersion(), BindingsVersion(); lib != bindings {
+		return fmt.Errorf("libvictor version mismatch: linked %s, bindings built against %s", lib, bindings)
+	}
+	return nil
 }
 
 // AllocIndex creates a new index
 func AllocIndex(indexType, method int, dims uint16) (*Index, error) {
-	idx := C.alloc_index(C.int(indexType), C.int(method), C.uint16_t(dims))
-	if idx == nil {
-		return nil, fmt.Errorf("Failed to allocate index")
+	if err := CheckLibraryVersion(); err != nil {
+		return nil, err
+	}
+	if _, ok := IndexTypes[indexType]; !ok {
+		return nil, fmt.Errorf("Invalid index type: %d", indexType)
 	}
-	return &Index{ptr: idx}, nil
+	if _, ok := Methods[method]; !ok {
+		return nil, fmt.Errorf("Invalid method: %d", method)
+	}
+	if dims == 0 {
+		return nil, ErrInvalidDimensions
+	}
+	h, err := allocHandle(indexType, method, dims)
+	if err != nil {
+		return nil, err
+	}
+	return &Index{
+		handle:    h,
+		indexType: indexType,
+		method:    method,
+		dims:      dims,
+		ids:       make(map[uint64]struct{}),
+	}, nil
 }
 
-// Insert adds a vector to the index with a given ID
+// checkVector validates a query vector against the given dims and the index
+// dims, so a short vector never reaches the backend. An empty vector is reported as
+// ErrInvalidVector.
+func (idx *Index) checkVector(vector []float32, dims int) error {
+	if len(vector) == 0 {
+		return ErrInvalidVector
+	}
+	if len(vector) != dims || dims != int(idx.dims) {
+		return ErrInvalidDimensions
+	}
+	return nil
+}
+
+// Insert adds a vector to the index with a given ID. It returns ErrDuplicate
+// if the ID is already in the index and ErrInvalidID if it exceeds MaxID.
 func (idx *Index) Insert(id uint64, vector []float32) error {
-	if idx.ptr == nil {
+	if !idx.initialized() {
 		return fmt.Errorf("Index not initialized")
 	}
-	if len(vector) == 0 {
-		return fmt.Errorf("Empty vector")
+
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	if err := idx.validate(id, vector); err != nil {
+		return err
+	}
+	if idx.normCheck == NormCheckWarn {
+		if norm, ok := idx.unitNorm(vector); !ok {
+			slog.Warn("Inserting unnormalized vector into COSINE index", "id", id, "norm", norm)
+		}
 	}
 
-	cVector := (*C.float)(unsafe.Pointer(&vector[0]))
-	return toError(C.insert(idx.ptr, C.uint64_t(id), cVector, C.uint16_t(len(vector))))
+	if err := idx.insert(id, vector); err != nil {
+		return err
+	}
+	idx.ids[id] = struct{}{}
+	return nil
 }
 
 // Search finds the closest match for a given vector
 func (idx *Index) Search(vector []float32, dims int) (*MatchResult, error) {
-	if idx.ptr == nil {
+	if !idx.initialized() {
 		return nil, fmt.Errorf("Index not initialized")
 	}
-
-	var cResult C.MatchResult
-	cVector := (*C.float)(unsafe.Pointer(&vector[0]))
-	err := C.search(idx.ptr, cVector, C.uint16_t(dims), &cResult)
-	if e := toError(err); e != nil {
-		return nil, e
+	if err := idx.checkVector(vector, dims); err != nil {
+		return nil, err
 	}
-
-	return &MatchResult{
-		ID:       int(cResult.id),
-		Distance: float32(cResult.distance),
-	}, nil
+	return idx.search(vector)
 }
 
+// SearchN returns the n best matches for the vector. If the index holds
+// fewer than n vectors, only that many results are returned. The search scans
+// all stored vectors, so the results are exact; BruteForceSearchN gives the
+// same ground truth without an index. libvictor has no SearchN for
+// FLAT_INDEX_MP yet, so with cgo it returns ErrNotSupported on such an index
+// (the pure-Go index supports it).
 func (idx *Index) SearchN(vector []float32, dims, n int) ([]MatchResult, error) {
-	if idx == nil || idx.ptr == nil {
+	if idx == nil || !idx.initialized() {
 		return nil, fmt.Errorf("index is nil")
 	}
 	if n <= 0 {
 		return nil, fmt.Errorf("invalid number of results: %d", n)
 	}
+	if err := idx.checkVector(vector, dims); err != nil {
+		return nil, err
+	}
+	return idx.searchN(vector, n)
+}
+
+// SearchBatch runs SearchN for each of the given vectors. All vectors are
+// checked against dims before any search is issued.
+func (idx *Index) SearchBatch(vectors [][]float32, dims, n int) ([][]MatchResult, error) {
+	return idx.SearchBatchContext(context.Background(), vectors, dims, n)
+}
+
+// SearchBatchContext is SearchBatch, stopping between queries once ctx is done
+func (idx *Index) SearchBatchContext(ctx context.Context, vectors [][]float32, dims, n int) ([][]MatchResult, error) {
+	if idx == nil || !idx.initialized() {
+		return nil, fmt.Errorf("index is nil")
+	}
+	for i, vector := range vectors {
+		if err := idx.checkVector(vector, dims); err != nil {
+			return nil, fmt.Errorf("vector %d: %w", i, err)
+		}
+	}
+
+	results := make([][]MatchResult, len(vectors))
+	for i, vector := range vectors {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		r, err := idx.SearchN(vector, dims, n)
+		if err != nil {
+			return nil, fmt.Errorf("vector %d: %w", i, err)
+		}
+		results[i] = r
+	}
+	return results, nil
+}
+
+// toFloat32 down-converts a float64 vector. Values are rounded to the
+// nearest float32, so precision beyond ~7 significant digits is lost and
+// magnitudes beyond float32 range become ±Inf.
+func toFloat32(vector []float64) []float32 {
+	out := make([]float32, len(vector))
+	for i, v := range vector {
+		out[i] = float32(v)
+	}
+	return out
+}
+
+// InsertFloat64 is Insert for float64 vectors. The index stores float32, see
+// toFloat32 for the precision loss.
+func (idx *Index) InsertFloat64(id uint64, vector []float64) error {
+	return idx.Insert(id, toFloat32(vector))
+}
+
+// SearchFloat64 is Search for float64 query vectors
+func (idx *Index) SearchFloat64(vector []float64, dims int) (*MatchResult, error) {
+	return idx.Search(toFloat32(vector), dims)
+}
+
+// SearchNFloat64 is SearchN for float64 query vectors
+func (idx *Index) SearchNFloat64(vector []float64, dims, n int) ([]MatchResult, error) {
+	return idx.SearchN(toFloat32(vector), dims, n)
+}
+
+// InsertContext is Insert, skipped if ctx is already done. The insert itself
+// can't be interrupted.
+func (idx *Index) InsertContext(ctx context.Context, id uint64, vector []float32) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return idx.Insert(id, vector)
+}
+
+// SearchContext is Search, skipped if ctx is already done
+func (idx *Index) SearchContext(ctx context.Context, vector []float32, dims int) (*MatchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return idx.Search(vector, dims)
+}
+
+// SearchNContext is SearchN, skipped if ctx is already done
+func (idx *Index) SearchNContext(ctx context.Context, vector []float32, dims, n int) ([]MatchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return idx.SearchN(vector, dims, n)
+}
 
-	// Convertir el vector Go a un puntero C
-	cVector := (*C.float)(unsafe.Pointer(&vector[0]))
+// QueryStats summarizes the distances of the top-N matches of a query
+type QueryStats struct {
+	Count int     `json:"count"`
+	Min   float32 `json:"min"`
+	Max   float32 `json:"max"`
+	Mean  float32 `json:"mean"`
+	Gap   float32 `json:"gap"` // distance between the 1st and 2nd match
+}
 
-	// Crear un buffer en C para almacenar los resultados
-	var cResults *C.MatchResult
+// QueryProfile runs a single SearchN and returns the distance statistics
+// of the results along with the results themselves. Count is below n when
+// the index holds fewer than n vectors.
+func (idx *Index) QueryProfile(vector []float32, dims, n int) (*QueryStats, []MatchResult, error) {
+	results, err := idx.SearchN(vector, dims, n)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// Llamar a la función C
-	err := C.search_n(idx.ptr, cVector, C.uint16_t(dims), &cResults, C.int(n))
-	if e := toError(err); e != nil {
-		return nil, e
+	stats := &QueryStats{Count: len(results)}
+	if len(results) == 0 {
+		return stats, results, nil
 	}
 
-	// Convertir los resultados de C a un slice de Go
-	cResultsSlice := unsafe.Slice(cResults, n)
-	results := make([]MatchResult, n)
-	for i := 0; i < n; i++ {
-		results[i] = MatchResult{
-			ID:       int(cResultsSlice[i].id),
-			Distance: float32(cResultsSlice[i].distance),
+	var sum float64
+	stats.Min = results[0].Distance
+	stats.Max = results[0].Distance
+	for _, r := range results {
+		if r.Distance < stats.Min {
+			stats.Min = r.Distance
 		}
+		if r.Distance > stats.Max {
+			stats.Max = r.Distance
+		}
+		sum += float64(r.Distance)
+	}
+	stats.Mean = float32(sum / float64(len(results)))
+	if len(results) > 1 {
+		stats.Gap = float32(math.Abs(float64(results[0].Distance - results[1].Distance)))
 	}
+	return stats, results, nil
+}
 
-	C.free(unsafe.Pointer(cResults))
-	fmt.Println(results)
-	return results, nil
+// IndexStats describes an index and its contents
+type IndexStats struct {
+	IndexType int    `json:"index_type"`
+	Method    int    `json:"method"`
+	Dims      int    `json:"dims"`
+	Vectors   uint64 `json:"vectors"`
+	Memory    uint64 `json:"memory_bytes"` // estimate, see MemoryUsage
+}
+
+// Size returns the number of vectors stored in the index
+func (idx *Index) Size() (uint64, error) {
+	if !idx.initialized() {
+		return 0, fmt.Errorf("Index not initialized")
+	}
+	return idx.size()
+}
+
+// IDs returns the IDs of every vector in the index, in ascending order. The
+// C index can't enumerate its contents, so they come from the set Insert and
+// Delete keep on the Go side.
+func (idx *Index) IDs() ([]uint64, error) {
+	if !idx.initialized() {
+		return nil, fmt.Errorf("Index not initialized")
+	}
+
+	idx.mutex.Lock()
+	ids := make([]uint64, 0, len(idx.ids))
+	for id := range idx.ids {
+		ids = append(ids, id)
+	}
+	idx.mutex.Unlock()
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// MemoryUsage estimates the bytes held by the stored vectors: the vector
+// data and per-vector bookkeeping of the backend, not allocator overhead or
+// the Go-side ID set.
+func (idx *Index) MemoryUsage() (uint64, error) {
+	n, err := idx.Size()
+	if err != nil {
+		return 0, err
+	}
+	return n * idx.bytesPerVector(), nil
+}
+
+// Stats returns the configuration, vector count and estimated memory usage
+// of the index
+func (idx *Index) Stats() (*IndexStats, error) {
+	n, err := idx.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	return &IndexStats{
+		IndexType: idx.indexType,
+		Method:    idx.method,
+		Dims:      int(idx.dims),
+		Vectors:   n,
+		Memory:    n * idx.bytesPerVector(),
+	}, nil
+}
+
+// Dims returns the number of dimensions the index was allocated with
+func (idx *Index) Dims() int {
+	return int(idx.dims)
+}
+
+// Method returns the distance method the index was allocated with
+func (idx *Index) Method() int {
+	return idx.method
+}
+
+// IndexType returns the type the index was allocated with
+func (idx *Index) IndexType() int {
+	return idx.indexType
 }
 
 // Delete removes a vector from the index by its ID
 func (idx *Index) Delete(id uint64) error {
-	if idx.ptr == nil {
+	if !idx.initialized() {
 		return fmt.Errorf("Index not initialized")
 	}
-	return toError(C.delete(idx.ptr, C.uint64_t(id)))
+
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	if err := idx.delete(id); err != nil {
+		return err
+	}
+	delete(idx.ids, id)
+	return nil
 }
 
 // DestroyIndex releases index memory
 func (idx *Index) DestroyIndex() {
-	if idx.ptr != nil {
-		C.destroy_index(&idx.ptr)
-		idx.ptr = nil
+	if idx.initialized() {
+		idx.destroy()
 	}
 }
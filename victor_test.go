@@ -0,0 +1,274 @@
+package victor
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// newTestIndex allocates a FLAT_INDEX and inserts vectors under ID i+1
+func newTestIndex(t *testing.T, method int, dims uint16, vectors ...[]float32) *Index {
+	t.Helper()
+	idx, err := AllocIndex(FLAT_INDEX, method, dims)
+	if err != nil {
+		t.Fatalf("AllocIndex: %v", err)
+	}
+	t.Cleanup(idx.DestroyIndex)
+	for i, v := range vectors {
+		if err := idx.Insert(uint64(i+1), v); err != nil {
+			t.Fatalf("Insert(%d): %v", i+1, err)
+		}
+	}
+	return idx
+}
+
+func resultIDs(results []MatchResult) []int {
+	ids := make([]int, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestSearchNFewerVectorsThanN(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  int
+		vectors [][]float32
+		query   []float32
+		want    []int
+	}{
+		{
+			name:    "L2NORM",
+			method:  L2NORM,
+			vectors: [][]float32{{0, 0, 0}, {3, 0, 0}},
+			query:   []float32{1, 0, 0},
+			want:    []int{1, 2},
+		},
+		{
+			// ID 2 sits at the worst COSINE distance and must still be returned
+			name:    "COSINE",
+			method:  COSINE,
+			vectors: [][]float32{{0, 1, 0}, {-1, 0, 0}},
+			query:   []float32{1, 0, 0},
+			want:    []int{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := newTestIndex(t, tt.method, 3, tt.vectors...)
+			results, err := idx.SearchN(tt.query, 3, 5)
+			if err != nil {
+				t.Fatalf("SearchN: %v", err)
+			}
+			got := resultIDs(results)
+			if len(got) != len(tt.want) {
+				t.Fatalf("SearchN IDs = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("SearchN IDs = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestEmptyVector(t *testing.T) {
+	idx := newTestIndex(t, L2NORM, 3, []float32{1, 2, 3})
+
+	if err := idx.Insert(2, []float32{}); err != ErrInvalidVector {
+		t.Errorf("Insert: got %v, want %v", err, ErrInvalidVector)
+	}
+	if _, err := idx.Search([]float32{}, 3); err != ErrInvalidVector {
+		t.Errorf("Search: got %v, want %v", err, ErrInvalidVector)
+	}
+	if _, err := idx.SearchN([]float32{}, 3, 1); err != ErrInvalidVector {
+		t.Errorf("SearchN: got %v, want %v", err, ErrInvalidVector)
+	}
+}
+
+func TestEmptyIndex(t *testing.T) {
+	idx := newTestIndex(t, L2NORM, 3)
+
+	if _, err := idx.Search([]float32{1, 2, 3}, 3); err != ErrIndexEmpty {
+		t.Errorf("Search: got %v, want %v", err, ErrIndexEmpty)
+	}
+	if _, err := idx.SearchN([]float32{1, 2, 3}, 3, 1); err != ErrIndexEmpty {
+		t.Errorf("SearchN: got %v, want %v", err, ErrIndexEmpty)
+	}
+
+	// Insert into an empty index succeeds, and the index is searchable after it
+	if err := idx.Insert(1, []float32{1, 2, 3}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if _, err := idx.Search([]float32{1, 2, 3}, 3); err != nil {
+		t.Errorf("Search after Insert: %v", err)
+	}
+}
+
+func TestWrongDimensions(t *testing.T) {
+	idx := newTestIndex(t, L2NORM, 3, []float32{1, 2, 3})
+
+	tests := []struct {
+		name   string
+		vector []float32
+		dims   int
+	}{
+		{"short vector", []float32{1, 2}, 3},
+		{"long vector", []float32{1, 2, 3, 4}, 3},
+		{"dims differ from the index", []float32{1, 2}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := idx.Search(tt.vector, tt.dims); err != ErrInvalidDimensions {
+				t.Errorf("Search: got %v, want %v", err, ErrInvalidDimensions)
+			}
+			if _, err := idx.SearchN(tt.vector, tt.dims, 1); err != ErrInvalidDimensions {
+				t.Errorf("SearchN: got %v, want %v", err, ErrInvalidDimensions)
+			}
+			batch := [][]float32{{1, 2, 3}, tt.vector}
+			if _, err := idx.SearchBatch(batch, tt.dims, 1); !errors.Is(err, ErrInvalidDimensions) {
+				t.Errorf("SearchBatch: got %v, want %v", err, ErrInvalidDimensions)
+			}
+		})
+	}
+}
+
+func TestIDOutOfRange(t *testing.T) {
+	idx := newTestIndex(t, L2NORM, 2)
+
+	// An ID that truncates to -1 in the C index, the empty slot marker
+	if err := idx.Insert(0xFFFFFFFF, []float32{0, 0}); !errors.Is(err, ErrInvalidID) {
+		t.Fatalf("Insert(0xFFFFFFFF): got %v, want %v", err, ErrInvalidID)
+	}
+	for _, id := range []uint64{MaxID, 1, 2} {
+		if err := idx.Insert(id, []float32{float32(id % 10), 0}); err != nil {
+			t.Fatalf("Insert(%d): %v", id, err)
+		}
+	}
+
+	results, err := idx.SearchN([]float32{0, 0}, 2, 3)
+	if err != nil {
+		t.Fatalf("SearchN: %v", err)
+	}
+	got := resultIDs(results)
+	want := []int{1, 2, MaxID}
+	if len(got) != len(want) {
+		t.Fatalf("SearchN IDs = %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("SearchN IDs = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for _, v := range [][]float32{{3, 4}, {1, 1, 1}, {-2, 0, 0.5}} {
+		var sum float64
+		for _, x := range Normalize(v) {
+			sum += float64(x) * float64(x)
+		}
+		if norm := math.Sqrt(sum); math.Abs(norm-1) > 1e-6 {
+			t.Errorf("Normalize(%v) norm = %f, want 1", v, norm)
+		}
+	}
+
+	zero := []float32{0, 0, 0}
+	got := Normalize(zero)
+	if len(got) != len(zero) {
+		t.Fatalf("Normalize(%v) = %v, want %v", zero, got, zero)
+	}
+	for i := range got {
+		if got[i] != 0 {
+			t.Fatalf("Normalize(%v) = %v, want %v", zero, got, zero)
+		}
+	}
+
+	// COSINE ignores magnitude, so normalizing beforehand must not change results
+	vectors := [][]float32{{1, 2, 3}, {-4, 0, 2}, {5, 5, 0}}
+	query := []float32{2, 1, 0}
+	normalized := make([][]float32, len(vectors))
+	for i, v := range vectors {
+		normalized[i] = Normalize(v)
+	}
+	raw, err := newTestIndex(t, COSINE, 3, vectors...).SearchN(query, 3, 3)
+	if err != nil {
+		t.Fatalf("SearchN: %v", err)
+	}
+	unit, err := newTestIndex(t, COSINE, 3, normalized...).SearchN(Normalize(query), 3, 3)
+	if err != nil {
+		t.Fatalf("SearchN normalized: %v", err)
+	}
+	if len(raw) != len(unit) {
+		t.Fatalf("SearchN IDs = %v, normalized %v", resultIDs(raw), resultIDs(unit))
+	}
+	for i := range raw {
+		if raw[i].ID != unit[i].ID || math.Abs(float64(raw[i].Distance-unit[i].Distance)) > 1e-5 {
+			t.Fatalf("SearchN = %v, normalized %v", raw, unit)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	idx := newTestIndex(t, COSINE, 3, []float32{1, 0, 0})
+	if err := idx.SetNormCheck(NormCheckStrict, 0); err != nil {
+		t.Fatalf("SetNormCheck: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		id     uint64
+		vector []float32
+		want   error
+	}{
+		{"duplicate ID", 1, []float32{0, 1, 0}, ErrDuplicate},
+		{"wrong dimensions", 2, []float32{0, 1}, ErrInvalidDimensions},
+		{"not normalized", 2, []float32{0, 2, 0}, ErrNotNormalized},
+		{"valid", 2, []float32{0, 1, 0}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := idx.Validate(tt.id, tt.vector)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Validate: got %v, want %v", err, tt.want)
+			}
+			size, err := idx.Size()
+			if err != nil {
+				t.Fatalf("Size: %v", err)
+			}
+			if size != 1 {
+				t.Fatalf("Size after Validate = %d, want 1", size)
+			}
+			if tt.want == nil {
+				return
+			}
+			if err := idx.Insert(tt.id, tt.vector); !errors.Is(err, tt.want) {
+				t.Fatalf("Insert: got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetNormCheckStrict(t *testing.T) {
+	tests := []struct {
+		name   string
+		method int
+		want   error
+	}{
+		{"COSINE", COSINE, ErrNotNormalized},
+		{"L2NORM", L2NORM, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := newTestIndex(t, tt.method, 2)
+			if err := idx.SetNormCheck(NormCheckStrict, 0); err != nil {
+				t.Fatalf("SetNormCheck: %v", err)
+			}
+			if err := idx.Insert(1, []float32{2, 0}); !errors.Is(err, tt.want) {
+				t.Fatalf("Insert norm 2: got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}